@@ -0,0 +1,29 @@
+// Package airway runs the flights of the templates and renders their Airway like yoke-base does, adding the
+// additionalPrinterColumns the custom resources show in kubectl get.
+package airway
+
+import (
+	"encoding/json"
+
+	stolos_yoke "github.com/stolos-cloud/stolos/yoke-base/pkg/stolos-yoke"
+	airways "github.com/yokecd/yoke/pkg/apis/airway/v1alpha1"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Build returns the Airway of the custom resource T served by the flight at flightURL, with columns added to every
+// version of the generated CRD.
+func Build[T any](inputs stolos_yoke.AirwayInputs, flightURL string, columns []apiextv1.CustomResourceColumnDefinition) ([]byte, error) {
+	data, err := stolos_yoke.BuildAirwayFor[T](inputs, flightURL)
+	if err != nil || len(columns) == 0 {
+		return data, err
+	}
+
+	var airway airways.Airway
+	if err := json.Unmarshal(data, &airway); err != nil {
+		return nil, err
+	}
+	for i := range airway.Spec.Template.Versions {
+		airway.Spec.Template.Versions[i].AdditionalPrinterColumns = columns
+	}
+	return json.Marshal(airway)
+}
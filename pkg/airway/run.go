@@ -0,0 +1,14 @@
+//go:build !airway
+
+package airway
+
+import (
+	stolos_yoke "github.com/stolos-cloud/stolos/yoke-base/pkg/stolos-yoke"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Run runs the flight: render receives the custom resource on stdin and its output is written to stdout.
+// Built with the airway tag, Run prints the Airway instead.
+func Run[T any](inputs stolos_yoke.AirwayInputs, columns []apiextv1.CustomResourceColumnDefinition, render func() ([]byte, error)) {
+	stolos_yoke.Run[T](inputs, render)
+}
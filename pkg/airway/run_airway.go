@@ -0,0 +1,29 @@
+//go:build airway
+
+package airway
+
+import (
+	"flag"
+	"os"
+
+	stolos_yoke "github.com/stolos-cloud/stolos/yoke-base/pkg/stolos-yoke"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Run prints the Airway of the flight published at the -flight-url flag.
+func Run[T any](inputs stolos_yoke.AirwayInputs, columns []apiextv1.CustomResourceColumnDefinition, render func() ([]byte, error)) {
+	flightURL := flag.String("flight-url", "", "flight url")
+	flag.Parse()
+
+	if *flightURL == "" {
+		panic("flight url is required")
+	}
+
+	airway, err := Build[T](inputs, *flightURL, columns)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := os.Stdout.Write(airway); err != nil {
+		panic(err)
+	}
+}
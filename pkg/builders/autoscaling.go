@@ -0,0 +1,123 @@
+package builders
+
+import (
+	"fmt"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AutoscalingSpec configures a HorizontalPodAutoscaler for an application Deployment.
+// Utilization targets are percentages of the container resource requests, so the container must declare them.
+type AutoscalingSpec struct {
+	Enabled     bool  `json:"enabled"`
+	MinReplicas int32 `json:"minReplicas,omitempty" Default:"1"`
+	MaxReplicas int32 `json:"maxReplicas"`
+	// TargetCPUUtilizationPercentage defaults to 80 when no target is set.
+	TargetCPUUtilizationPercentage    int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	TargetMemoryUtilizationPercentage int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+	// ScaleDownStabilizationWindowSeconds overrides the default 300 second window used before scaling down.
+	ScaleDownStabilizationWindowSeconds *int32 `json:"scaleDownStabilizationWindowSeconds,omitempty"`
+}
+
+// IsEnabled reports whether an HPA should be emitted. It is safe to call on a nil spec.
+func (spec *AutoscalingSpec) IsEnabled() bool {
+	return spec != nil && spec.Enabled
+}
+
+// Validate checks the replica bounds and targets when autoscaling is enabled.
+func (spec *AutoscalingSpec) Validate(path string) error {
+	if !spec.IsEnabled() {
+		return nil
+	}
+	if spec.MaxReplicas <= 0 {
+		return fmt.Errorf("%s.maxReplicas is required when autoscaling is enabled", path)
+	}
+	if spec.MinReplicas < 0 {
+		return fmt.Errorf("%s.minReplicas cannot be negative", path)
+	}
+	if spec.MinReplicas > spec.MaxReplicas {
+		return fmt.Errorf("%s.minReplicas (%d) cannot exceed maxReplicas (%d)", path, spec.MinReplicas, spec.MaxReplicas)
+	}
+	if spec.TargetCPUUtilizationPercentage < 0 {
+		return fmt.Errorf("%s.targetCPUUtilizationPercentage cannot be negative", path)
+	}
+	if spec.TargetMemoryUtilizationPercentage < 0 {
+		return fmt.Errorf("%s.targetMemoryUtilizationPercentage cannot be negative", path)
+	}
+	if window := spec.ScaleDownStabilizationWindowSeconds; window != nil && (*window < 0 || *window > 3600) {
+		return fmt.Errorf("%s.scaleDownStabilizationWindowSeconds must be between 0 and 3600", path)
+	}
+	return nil
+}
+
+// SetDefaults fills in the optional fields when autoscaling is enabled.
+func (spec *AutoscalingSpec) SetDefaults() {
+	if !spec.IsEnabled() {
+		return
+	}
+	if spec.MinReplicas == 0 {
+		spec.MinReplicas = 1
+	}
+	if spec.TargetCPUUtilizationPercentage == 0 && spec.TargetMemoryUtilizationPercentage == 0 {
+		spec.TargetCPUUtilizationPercentage = 80
+	}
+}
+
+// HorizontalPodAutoscaler returns the autoscaling/v2 HPA scaling the Deployment, or nil when autoscaling is disabled.
+func (d Deployment) HorizontalPodAutoscaler() *autoscalingv2.HorizontalPodAutoscaler {
+	if !d.Autoscaling.IsEnabled() {
+		return nil
+	}
+	spec := d.Autoscaling
+	minReplicas := spec.MinReplicas
+
+	var metrics []autoscalingv2.MetricSpec
+	for _, target := range []struct {
+		resource    corev1.ResourceName
+		utilization int32
+	}{
+		{corev1.ResourceCPU, spec.TargetCPUUtilizationPercentage},
+		{corev1.ResourceMemory, spec.TargetMemoryUtilizationPercentage},
+	} {
+		if target.utilization == 0 {
+			continue
+		}
+		utilization := target.utilization
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: target.resource,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: &utilization,
+				},
+			},
+		})
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta:   metav1.TypeMeta{APIVersion: autoscalingv2.SchemeGroupVersion.Identifier(), Kind: "HorizontalPodAutoscaler"},
+		ObjectMeta: objectMeta(d.Name, d.Namespace, nil),
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       d.Name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: spec.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+
+	if window := spec.ScaleDownStabilizationWindowSeconds; window != nil {
+		stabilization := *window
+		hpa.Spec.Behavior = &autoscalingv2.HorizontalPodAutoscalerBehavior{
+			ScaleDown: &autoscalingv2.HPAScalingRules{StabilizationWindowSeconds: &stabilization},
+		}
+	}
+
+	return hpa
+}
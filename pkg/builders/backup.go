@@ -0,0 +1,169 @@
+package builders
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// BarmanCloudPlugin is the CNPG-I plugin installed by the platform (cnpg.barmanVersion in system/stolos-system.yml).
+const BarmanCloudPlugin = "barman-cloud.cloudnative-pg.io"
+
+// DatabaseBackupSpec configures continuous WAL archiving and scheduled base backups to an S3 compatible object store
+// through the Barman Cloud plugin.
+type DatabaseBackupSpec struct {
+	Enabled bool `json:"enabled"`
+	// DestinationPath is the bucket and prefix receiving the backups, e.g. "s3://backups/my-app".
+	DestinationPath string `json:"destinationPath"`
+	// EndpointURL points to the object store when it is not AWS S3, e.g. "http://minio.minio:9000".
+	EndpointURL string `json:"endpointURL,omitempty"`
+	// CredentialsSecret names a Secret holding the ACCESS_KEY_ID and ACCESS_SECRET_KEY keys.
+	CredentialsSecret string `json:"credentialsSecret"`
+	// RetentionPolicy removes backups older than the given number of days, weeks or months, e.g. "30d".
+	RetentionPolicy string `json:"retentionPolicy,omitempty" Default:"\"30d\""`
+	// Schedule is a six field cron expression, seconds first, e.g. "0 0 2 * * *" for every day at 02:00.
+	Schedule string `json:"schedule,omitempty" Default:"\"0 0 0 * * *\""`
+}
+
+var backupRetentionPolicy = regexp.MustCompile(`^[1-9][0-9]*[dwm]$`)
+
+// IsEnabled reports whether backups are configured. It is safe to call on a nil spec.
+func (spec *DatabaseBackupSpec) IsEnabled() bool {
+	return spec != nil && spec.Enabled
+}
+
+// Validate checks the backup settings when backups are enabled.
+func (spec *DatabaseBackupSpec) Validate(path string) error {
+	if !spec.IsEnabled() {
+		return nil
+	}
+	if !strings.HasPrefix(spec.DestinationPath, "s3://") {
+		return fmt.Errorf("%s.destinationPath must be an s3:// URL", path)
+	}
+	if spec.EndpointURL != "" && !strings.HasPrefix(spec.EndpointURL, "http://") && !strings.HasPrefix(spec.EndpointURL, "https://") {
+		return fmt.Errorf("%s.endpointURL must be an http:// or https:// URL", path)
+	}
+	if spec.CredentialsSecret == "" {
+		return fmt.Errorf("%s.credentialsSecret is required", path)
+	}
+	if spec.RetentionPolicy != "" && !backupRetentionPolicy.MatchString(spec.RetentionPolicy) {
+		return fmt.Errorf("%s.retentionPolicy %q must be a number of days, weeks or months, e.g. 30d, 4w or 6m", path, spec.RetentionPolicy)
+	}
+	if spec.Schedule != "" {
+		if fields := len(strings.Fields(spec.Schedule)); fields != 6 {
+			return fmt.Errorf("%s.schedule %q must have 6 fields, seconds first (got %d)", path, spec.Schedule, fields)
+		}
+	}
+	return nil
+}
+
+// SetDefaults fills in the optional fields when backups are enabled.
+func (spec *DatabaseBackupSpec) SetDefaults() {
+	if !spec.IsEnabled() {
+		return
+	}
+	if spec.RetentionPolicy == "" {
+		spec.RetentionPolicy = "30d"
+	}
+	if spec.Schedule == "" {
+		spec.Schedule = "0 0 0 * * *"
+	}
+}
+
+// ObjectStoreName is the Barman Cloud ObjectStore holding the backups of the cluster.
+func (db Database) ObjectStoreName() string {
+	return fmt.Sprintf("%s-backup", db.Spec.ClusterName)
+}
+
+// ObjectStore returns the barmancloud.cnpg.io/v1 ObjectStore, or nil when backups are disabled.
+func (db Database) ObjectStore() *unstructured.Unstructured {
+	backup := db.Spec.Backup
+	if !backup.IsEnabled() {
+		return nil
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "barmancloud.cnpg.io/v1",
+			"kind":       "ObjectStore",
+			"metadata": map[string]interface{}{
+				"name":      db.ObjectStoreName(),
+				"namespace": db.Namespace,
+			},
+			"spec": map[string]interface{}{
+				"configuration":   objectStoreConfiguration(backup.DestinationPath, backup.EndpointURL, backup.CredentialsSecret),
+				"retentionPolicy": backup.RetentionPolicy,
+			},
+		},
+	}
+}
+
+// ScheduledBackup returns the postgresql.cnpg.io/v1 ScheduledBackup taking base backups through the plugin, or nil
+// when backups are disabled.
+func (db Database) ScheduledBackup() *unstructured.Unstructured {
+	if !db.Spec.Backup.IsEnabled() {
+		return nil
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "postgresql.cnpg.io/v1",
+			"kind":       "ScheduledBackup",
+			"metadata": map[string]interface{}{
+				"name":      db.Spec.ClusterName,
+				"namespace": db.Namespace,
+			},
+			"spec": map[string]interface{}{
+				"schedule": db.Spec.Backup.Schedule,
+				// Backups belong to the ScheduledBackup and are garbage collected with it.
+				"backupOwnerReference": "self",
+				"cluster": map[string]interface{}{
+					"name": db.Spec.ClusterName,
+				},
+				"method": "plugin",
+				"pluginConfiguration": map[string]interface{}{
+					"name": BarmanCloudPlugin,
+				},
+			},
+		},
+	}
+}
+
+// barmanPlugin is the Cluster spec.plugins entry archiving the WALs to the ObjectStore.
+func (db Database) barmanPlugin() map[string]interface{} {
+	return map[string]interface{}{
+		"name":          BarmanCloudPlugin,
+		"isWALArchiver": true,
+		"parameters": map[string]interface{}{
+			"barmanObjectName": db.ObjectStoreName(),
+		},
+	}
+}
+
+// objectStoreConfiguration returns the ObjectStore spec.configuration of an S3 compatible store. The credentials
+// Secret holds the ACCESS_KEY_ID and ACCESS_SECRET_KEY keys.
+func objectStoreConfiguration(destinationPath, endpointURL, credentialsSecret string) map[string]interface{} {
+	configuration := map[string]interface{}{
+		"destinationPath": destinationPath,
+		"s3Credentials": map[string]interface{}{
+			"accessKeyId":     secretKeySelector(credentialsSecret, "ACCESS_KEY_ID"),
+			"secretAccessKey": secretKeySelector(credentialsSecret, "ACCESS_SECRET_KEY"),
+		},
+		"wal": map[string]interface{}{
+			"compression": "gzip",
+		},
+		"data": map[string]interface{}{
+			"compression": "gzip",
+		},
+	}
+	if endpointURL != "" {
+		configuration["endpointURL"] = endpointURL
+	}
+	return configuration
+}
+
+func secretKeySelector(name, key string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "key": key}
+}
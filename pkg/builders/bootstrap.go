@@ -0,0 +1,274 @@
+package builders
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DatabaseBootstrapSpec creates the cluster from existing data instead of an empty database. At most one source may be
+// set; when none is, the database is initialized empty.
+type DatabaseBootstrapSpec struct {
+	Recovery     *RecoveryBootstrapSpec     `json:"recovery,omitempty"`
+	PgBasebackup *PgBasebackupBootstrapSpec `json:"pgBasebackup,omitempty"`
+	Import       *ImportBootstrapSpec       `json:"import,omitempty"`
+}
+
+// RecoveryBootstrapSpec restores the backups another cluster wrote to an object store through the Barman Cloud plugin.
+// The store is either an existing ObjectStore in the namespace, or described inline with the same fields as backups.
+type RecoveryBootstrapSpec struct {
+	// ServerName is the name of the cluster that wrote the backups.
+	ServerName string `json:"serverName"`
+	// ObjectStore names an existing barmancloud.cnpg.io ObjectStore, e.g. "<clusterName>-backup" of another template.
+	ObjectStore       string `json:"objectStore,omitempty"`
+	DestinationPath   string `json:"destinationPath,omitempty"`
+	EndpointURL       string `json:"endpointURL,omitempty"`
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+	// TargetTime enables point-in-time recovery up to the given RFC 3339 timestamp, e.g. "2025-01-31T23:00:00Z".
+	// The latest state of the archive is restored when empty.
+	TargetTime string `json:"targetTime,omitempty"`
+}
+
+// PgBasebackupBootstrapSpec clones a running CNPG cluster of the same namespace and PostgreSQL major version through
+// streaming replication, using the replication certificates CNPG generates for it.
+type PgBasebackupBootstrapSpec struct {
+	SourceCluster string `json:"sourceCluster"`
+}
+
+// ImportBootstrapSpec initializes the database with a logical copy (pg_dump / pg_restore) of a database served by any
+// reachable PostgreSQL server, which also works across major versions.
+type ImportBootstrapSpec struct {
+	Host string `json:"host"`
+	Port int32  `json:"port,omitempty" Default:"5432"`
+	// Database is the database to import. It defaults to the databaseName of the new cluster.
+	Database string `json:"database,omitempty"`
+	User     string `json:"user"`
+	// PasswordSecret names a Secret holding the password of User under the "password" key.
+	PasswordSecret string `json:"passwordSecret"`
+	SSLMode        string `json:"sslMode,omitempty" Default:"\"require\""`
+}
+
+// externalClusterName is the name the bootstrap source is registered under in the Cluster externalClusters.
+const externalClusterName = "origin"
+
+// Validate rejects the bootstrap configurations CNPG would refuse. Path is the location of the bootstrap spec, e.g.
+// "spec.database.bootstrap", and spec is the database being bootstrapped.
+func (bootstrap *DatabaseBootstrapSpec) Validate(path string, spec DatabaseSpec) error {
+	if bootstrap == nil {
+		return nil
+	}
+
+	if bootstrap.sources() > 1 {
+		return fmt.Errorf("%s can only set one of recovery, pgBasebackup or import", path)
+	}
+
+	switch {
+	case bootstrap.Recovery != nil:
+		return bootstrap.Recovery.validate(path+".recovery", spec)
+	case bootstrap.PgBasebackup != nil:
+		source := bootstrap.PgBasebackup.SourceCluster
+		if source == "" {
+			return fmt.Errorf("%s.pgBasebackup.sourceCluster is required", path)
+		}
+		if source == spec.ClusterName {
+			return fmt.Errorf("%s.pgBasebackup.sourceCluster cannot be the cluster itself", path)
+		}
+	case bootstrap.Import != nil:
+		for _, field := range []struct{ name, value string }{
+			{"host", bootstrap.Import.Host},
+			{"user", bootstrap.Import.User},
+			{"passwordSecret", bootstrap.Import.PasswordSecret},
+		} {
+			if field.value == "" {
+				return fmt.Errorf("%s.import.%s is required", path, field.name)
+			}
+		}
+		if bootstrap.Import.Port < 0 || bootstrap.Import.Port > 65535 {
+			return fmt.Errorf("%s.import.port must be between 1 and 65535", path)
+		}
+	}
+	return nil
+}
+
+// sources counts the sources set, none for a nil or empty spec.
+func (bootstrap *DatabaseBootstrapSpec) sources() int {
+	if bootstrap == nil {
+		return 0
+	}
+	sources := 0
+	for _, set := range []bool{bootstrap.Recovery != nil, bootstrap.PgBasebackup != nil, bootstrap.Import != nil} {
+		if set {
+			sources++
+		}
+	}
+	return sources
+}
+
+func (recovery RecoveryBootstrapSpec) validate(path string, spec DatabaseSpec) error {
+	if recovery.ServerName == "" {
+		return fmt.Errorf("%s.serverName is required", path)
+	}
+	inline := recovery.DestinationPath != "" || recovery.EndpointURL != "" || recovery.CredentialsSecret != ""
+	if (recovery.ObjectStore != "") == inline {
+		return fmt.Errorf("%s must set either objectStore or destinationPath and credentialsSecret", path)
+	}
+	if inline {
+		if !strings.HasPrefix(recovery.DestinationPath, "s3://") {
+			return fmt.Errorf("%s.destinationPath must be an s3:// URL", path)
+		}
+		if recovery.CredentialsSecret == "" {
+			return fmt.Errorf("%s.credentialsSecret is required", path)
+		}
+	}
+	if recovery.TargetTime != "" {
+		if _, err := time.Parse(time.RFC3339, recovery.TargetTime); err != nil {
+			return fmt.Errorf("%s.targetTime %q must be an RFC 3339 timestamp, e.g. 2025-01-31T23:00:00Z", path, recovery.TargetTime)
+		}
+	}
+
+	// CNPG refuses to archive WALs into a non-empty location, which is what restoring and backing up under the same
+	// server name in the same store amounts to.
+	if backup := spec.Backup; backup.IsEnabled() && recovery.ServerName == spec.ClusterName {
+		sameStore := recovery.ObjectStore == fmt.Sprintf("%s-backup", spec.ClusterName) ||
+			(inline && strings.TrimSuffix(recovery.DestinationPath, "/") == strings.TrimSuffix(backup.DestinationPath, "/"))
+		if sameStore {
+			return fmt.Errorf("%s reads the archive the cluster backs up to, use a different backup.destinationPath or restore from another serverName", path)
+		}
+	}
+	return nil
+}
+
+// SetDefaults fills in the optional fields of the import source.
+func (bootstrap *DatabaseBootstrapSpec) SetDefaults(spec DatabaseSpec) {
+	if bootstrap == nil || bootstrap.Import == nil {
+		return
+	}
+	if bootstrap.Import.Port == 0 {
+		bootstrap.Import.Port = 5432
+	}
+	if bootstrap.Import.Database == "" {
+		bootstrap.Import.Database = spec.DatabaseName
+	}
+	if bootstrap.Import.SSLMode == "" {
+		bootstrap.Import.SSLMode = "require"
+	}
+}
+
+// RecoveryObjectStoreName is the ObjectStore read when recovering from an inline object store.
+func (db Database) RecoveryObjectStoreName() string {
+	return fmt.Sprintf("%s-recovery", db.Spec.ClusterName)
+}
+
+// RecoveryObjectStore returns the ObjectStore described inline by the recovery source, or nil when recovery is not
+// used or reads an existing ObjectStore.
+func (db Database) RecoveryObjectStore() *unstructured.Unstructured {
+	bootstrap := db.Spec.Bootstrap
+	if bootstrap == nil || bootstrap.Recovery == nil || bootstrap.Recovery.ObjectStore != "" {
+		return nil
+	}
+	recovery := bootstrap.Recovery
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "barmancloud.cnpg.io/v1",
+			"kind":       "ObjectStore",
+			"metadata": map[string]interface{}{
+				"name":      db.RecoveryObjectStoreName(),
+				"namespace": db.Namespace,
+			},
+			"spec": map[string]interface{}{
+				"configuration": objectStoreConfiguration(recovery.DestinationPath, recovery.EndpointURL, recovery.CredentialsSecret),
+			},
+		},
+	}
+}
+
+// bootstrap returns the Cluster spec.bootstrap and the spec.externalClusters it reads from.
+func (db Database) bootstrap() (map[string]interface{}, []interface{}) {
+	// CNPG only generates the "<clusterName>-app" Secret when no user provided one.
+	application := map[string]interface{}{
+		"database": db.Spec.DatabaseName,
+	}
+	if db.Spec.CredentialsSecret != "" {
+		application["secret"] = map[string]interface{}{"name": db.Spec.CredentialsSecret}
+	}
+
+	// Validation rejects postInitApplicationSQL for recovery and pg_basebackup, so it only reaches initdb.
+	if len(db.Spec.PostInitApplicationSQL) > 0 {
+		application["postInitApplicationSQL"] = toInterfaces(db.Spec.PostInitApplicationSQL)
+	}
+
+	bootstrap := db.Spec.Bootstrap
+	switch {
+	case bootstrap.sources() == 0:
+		return map[string]interface{}{"initdb": application}, nil
+
+	case bootstrap.Recovery != nil:
+		recovery := bootstrap.Recovery
+		// The owner must be set for CNPG to reconcile the application user of the restored database.
+		application["owner"] = db.Spec.DatabaseName
+		application["source"] = externalClusterName
+		if recovery.TargetTime != "" {
+			application["recoveryTarget"] = map[string]interface{}{"targetTime": recovery.TargetTime}
+		}
+		store := recovery.ObjectStore
+		if store == "" {
+			store = db.RecoveryObjectStoreName()
+		}
+		origin := map[string]interface{}{
+			"name": externalClusterName,
+			"plugin": map[string]interface{}{
+				"name": BarmanCloudPlugin,
+				"parameters": map[string]interface{}{
+					"barmanObjectName": store,
+					"serverName":       recovery.ServerName,
+				},
+			},
+		}
+		return map[string]interface{}{"recovery": application}, []interface{}{origin}
+
+	case bootstrap.PgBasebackup != nil:
+		source := bootstrap.PgBasebackup.SourceCluster
+		application["owner"] = db.Spec.DatabaseName
+		application["source"] = externalClusterName
+		origin := map[string]interface{}{
+			"name": externalClusterName,
+			"connectionParameters": map[string]interface{}{
+				"host":    fmt.Sprintf("%s-rw", source),
+				"user":    "streaming_replica",
+				"sslmode": "verify-full",
+				"dbname":  "postgres",
+			},
+			"sslKey":      secretKeySelector(fmt.Sprintf("%s-replication", source), "tls.key"),
+			"sslCert":     secretKeySelector(fmt.Sprintf("%s-replication", source), "tls.crt"),
+			"sslRootCert": secretKeySelector(fmt.Sprintf("%s-ca", source), "ca.crt"),
+		}
+		return map[string]interface{}{"pg_basebackup": application}, []interface{}{origin}
+
+	case bootstrap.Import != nil:
+		source := bootstrap.Import
+		application["import"] = map[string]interface{}{
+			"type":      "microservice",
+			"databases": []interface{}{source.Database},
+			"source": map[string]interface{}{
+				"externalCluster": externalClusterName,
+			},
+		}
+		origin := map[string]interface{}{
+			"name": externalClusterName,
+			"connectionParameters": map[string]interface{}{
+				"host":    source.Host,
+				"port":    fmt.Sprintf("%d", source.Port),
+				"user":    source.User,
+				"dbname":  source.Database,
+				"sslmode": source.SSLMode,
+			},
+			"password": secretKeySelector(source.PasswordSecret, "password"),
+		}
+		return map[string]interface{}{"initdb": application}, []interface{}{origin}
+	}
+	// Validate rejects several sources, so every bootstrap reaching here was handled above.
+	return nil, nil
+}
@@ -0,0 +1,29 @@
+// Package builders contains the Kubernetes resource builders shared by the template scaffolds.
+//
+// Each builder is a plain struct describing the desired resource. Calling Build returns the typed object from
+// `k8s.io/api` (or an unstructured object for CRDs such as CloudNativePG clusters) ready to be emitted by a flight.
+// Fixes made here land in every template that imports the module.
+package builders
+
+import (
+	"maps"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// objectMeta returns the metadata shared by every generated resource.
+func objectMeta(name, namespace string, labels map[string]string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: namespace,
+		Labels:    labels,
+	}
+}
+
+// mergeLabels returns a new map holding labels overlaid with selector, so selectors always win.
+func mergeLabels(labels, selector map[string]string) map[string]string {
+	merged := make(map[string]string, len(labels)+len(selector))
+	maps.Copy(merged, labels)
+	maps.Copy(merged, selector)
+	return merged
+}
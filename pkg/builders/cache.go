@@ -0,0 +1,386 @@
+package builders
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CacheSpec configures the Redis / Valkey cache tier.
+type CacheSpec struct {
+	Flavor string `json:"flavor,omitempty" Default:"\"redis\""`
+	// Version selects a supported release line: 7.2 (default), 7.4 or 8.0 for redis, 8.1 (default), 8.0 or 7.2 for valkey.
+	Version string `json:"version,omitempty"`
+	// Image overrides the image entirely, e.g. to pull from a mirror. Any version is accepted with an override.
+	Image string `json:"image,omitempty"`
+	// Digest pins the image, e.g. "sha256:<64 hex characters>".
+	Digest string `json:"digest,omitempty"`
+	Port   int32  `json:"port,omitempty" Default:"6379"`
+	// Persistence runs the cache as a StatefulSet writing an append-only file to a PersistentVolumeClaim.
+	Persistence *CachePersistenceSpec `json:"persistence,omitempty"`
+	// Auth protects the cache with a password, exposed to the application as CACHE_PASSWORD.
+	Auth *CacheAuthSpec `json:"auth,omitempty"`
+	// MaxMemory caps the memory used by the dataset, e.g. "256mb". The server default is no limit.
+	MaxMemory string `json:"maxMemory,omitempty"`
+	// EvictionPolicy selects the keys evicted once MaxMemory is reached, e.g. "allkeys-lru".
+	EvictionPolicy string `json:"evictionPolicy,omitempty"`
+	// Probes default to running "<flavor>-cli ping" against the cache.
+	Probes    *ProbesSpec    `json:"probes,omitempty"`
+	Resources *ResourcesSpec `json:"resources,omitempty"`
+}
+
+// CachePersistenceSpec configures the volume of the cache StatefulSet.
+type CachePersistenceSpec struct {
+	Enabled bool   `json:"enabled"`
+	Size    string `json:"size,omitempty" Default:"\"1Gi\""`
+	// StorageClassName defaults to the default StorageClass of the cluster.
+	StorageClassName string `json:"storageClassName,omitempty"`
+}
+
+// CacheAuthSpec configures the cache password.
+type CacheAuthSpec struct {
+	Enabled bool `json:"enabled"`
+	// ExistingSecret names a Secret holding the password. When empty a password is generated into "<name>-cache-auth".
+	ExistingSecret string `json:"existingSecret,omitempty"`
+	// SecretKey is the key of the password in the Secret.
+	SecretKey string `json:"secretKey,omitempty" Default:"\"password\""`
+}
+
+// cacheFlavor lists the release lines supported for a flavor. The first version is the default.
+type cacheFlavor struct {
+	name       string
+	repository string
+	versions   []string
+}
+
+// cacheFlavors is the table of supported flavor / version pairs. Add a version here once it has been tested with the
+// configuration and probes rendered by Cache.
+var cacheFlavors = []cacheFlavor{
+	{name: "redis", repository: "docker.io/redis", versions: []string{"7.2", "7.4", "8.0"}},
+	{name: "valkey", repository: "docker.io/valkey/valkey", versions: []string{"8.1", "8.0", "7.2"}},
+}
+
+func lookupCacheFlavor(name string) (cacheFlavor, bool) {
+	if name == "" {
+		name = "redis"
+	}
+	for _, flavor := range cacheFlavors {
+		if flavor.name == strings.ToLower(name) {
+			return flavor, true
+		}
+	}
+	return cacheFlavor{}, false
+}
+
+var (
+	// cacheDigest matches the digests accepted after the "@" of an image reference.
+	cacheDigest = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+	// cacheMaxMemory matches the memory units understood by Redis and Valkey.
+	cacheMaxMemory = regexp.MustCompile(`^(?i)[0-9]+(k|kb|m|mb|g|gb)?$`)
+
+	cacheEvictionPolicies = []string{
+		"noeviction",
+		"allkeys-lru",
+		"allkeys-lfu",
+		"allkeys-random",
+		"volatile-lru",
+		"volatile-lfu",
+		"volatile-random",
+		"volatile-ttl",
+	}
+)
+
+// Validate checks the cache settings. Path is the location of the spec in the custom resource, e.g. "spec.cache".
+func (spec CacheSpec) Validate(path string) error {
+	flavor, ok := lookupCacheFlavor(spec.Flavor)
+	if !ok {
+		names := make([]string, 0, len(cacheFlavors))
+		for _, flavor := range cacheFlavors {
+			names = append(names, flavor.name)
+		}
+		return fmt.Errorf("%s.flavor %q is not supported, expected one of %s", path, spec.Flavor, strings.Join(names, ", "))
+	}
+	if spec.Version != "" && spec.Image == "" && !slices.Contains(flavor.versions, spec.Version) {
+		return fmt.Errorf("%s.version %q is not supported for %s, expected one of %s (or set %s.image)", path, spec.Version, flavor.name, strings.Join(flavor.versions, ", "), path)
+	}
+	if spec.Digest != "" {
+		if !cacheDigest.MatchString(spec.Digest) {
+			return fmt.Errorf("%s.digest %q must look like sha256:<64 hex characters>", path, spec.Digest)
+		}
+		if strings.Contains(spec.Image, "@") {
+			return fmt.Errorf("%s.digest cannot be set when %s.image already pins a digest", path, path)
+		}
+	}
+	if spec.MaxMemory != "" && !cacheMaxMemory.MatchString(spec.MaxMemory) {
+		return fmt.Errorf("%s.maxMemory %q must be a number of bytes optionally followed by kb, mb or gb", path, spec.MaxMemory)
+	}
+	if spec.EvictionPolicy != "" && !slices.Contains(cacheEvictionPolicies, spec.EvictionPolicy) {
+		return fmt.Errorf("%s.evictionPolicy %q must be one of %s", path, spec.EvictionPolicy, strings.Join(cacheEvictionPolicies, ", "))
+	}
+	if spec.Persistence.IsEnabled() && spec.Persistence.Size != "" {
+		if _, err := resource.ParseQuantity(spec.Persistence.Size); err != nil {
+			return fmt.Errorf("%s.persistence.size: %q is not a valid quantity", path, spec.Persistence.Size)
+		}
+	}
+	if err := spec.Probes.Validate(path + ".probes"); err != nil {
+		return err
+	}
+	return spec.Resources.Validate(path + ".resources")
+}
+
+// SetDefaults fills in the optional fields.
+func (spec *CacheSpec) SetDefaults() {
+	flavor, _ := lookupCacheFlavor(spec.Flavor)
+	spec.Flavor = flavor.name
+	if spec.Version == "" {
+		spec.Version = flavor.versions[0]
+	}
+	if spec.Port == 0 {
+		spec.Port = 6379
+	}
+	if spec.Persistence.IsEnabled() && spec.Persistence.Size == "" {
+		spec.Persistence.Size = "1Gi"
+	}
+	if spec.Auth.IsEnabled() && spec.Auth.SecretKey == "" {
+		spec.Auth.SecretKey = "password"
+	}
+}
+
+// IsEnabled reports whether the cache is persisted. It is safe to call on a nil spec.
+func (spec *CachePersistenceSpec) IsEnabled() bool {
+	return spec != nil && spec.Enabled
+}
+
+// IsEnabled reports whether the cache requires a password. It is safe to call on a nil spec.
+func (spec *CacheAuthSpec) IsEnabled() bool {
+	return spec != nil && spec.Enabled
+}
+
+// Cache builds the cache workload and Service for the application called AppName.
+type Cache struct {
+	AppName   string
+	Namespace string
+	Spec      CacheSpec
+}
+
+const (
+	cacheConfigDir  = "/etc/cache"
+	cacheConfigFile = "cache.conf"
+	cacheDataDir    = "/data"
+)
+
+// Name is shared by the cache workload and its Service.
+func (c Cache) Name() string {
+	return fmt.Sprintf("%s-cache", c.AppName)
+}
+
+// ConfigName is the ConfigMap holding the server configuration.
+func (c Cache) ConfigName() string {
+	return fmt.Sprintf("%s-cache-config", c.AppName)
+}
+
+// PasswordSecretName is the Secret holding the password when auth is enabled.
+func (c Cache) PasswordSecretName() string {
+	if c.Spec.Auth != nil && c.Spec.Auth.ExistingSecret != "" {
+		return c.Spec.Auth.ExistingSecret
+	}
+	return fmt.Sprintf("%s-cache-auth", c.AppName)
+}
+
+// Deployment returns the single-replica cache Deployment, used when persistence is disabled.
+func (c Cache) Deployment() *appsv1.Deployment {
+	return c.pod().Build()
+}
+
+// StatefulSet returns the single-replica cache StatefulSet with its data volume, used when persistence is enabled.
+func (c Cache) StatefulSet() *appsv1.StatefulSet {
+	persistence := c.Spec.Persistence
+	claim := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(persistence.Size)},
+			},
+		},
+	}
+	if persistence.StorageClassName != "" {
+		claim.Spec.StorageClassName = &persistence.StorageClassName
+	}
+
+	pod := c.pod()
+	pod.VolumeMounts = append(pod.VolumeMounts, corev1.VolumeMount{Name: "data", MountPath: cacheDataDir})
+
+	return StatefulSet{
+		Deployment:           pod,
+		ServiceName:          c.HeadlessServiceName(),
+		VolumeClaimTemplates: []corev1.PersistentVolumeClaim{claim},
+	}.Build()
+}
+
+// pod describes the cache container shared by the Deployment and the StatefulSet.
+func (c Cache) pod() Deployment {
+	// The entrypoint of the images starts the server when the first argument is a config file.
+	args := []string{cacheConfigDir + "/" + cacheConfigFile}
+	var env []corev1.EnvVar
+	if c.Spec.Auth.IsEnabled() {
+		args = append(args, "--requirepass", "$(CACHE_PASSWORD)")
+		env = []corev1.EnvVar{
+			{Name: "CACHE_PASSWORD", ValueFrom: SecretKeyRef(c.PasswordSecretName(), c.Spec.Auth.SecretKey)},
+			// Lets the CLI used by the default probes authenticate, valkey-cli reads the variable of redis-cli too.
+			{Name: "REDISCLI_AUTH", Value: "$(CACHE_PASSWORD)"},
+		}
+	}
+
+	return Deployment{
+		Name:          c.Name(),
+		Namespace:     c.Namespace,
+		Replicas:      1,
+		ContainerName: "cache",
+		Image:         c.Image(),
+		// Roll the pod when the configuration changes, the server only reads it on startup.
+		PodAnnotations: map[string]string{"checksum/config": c.configChecksum()},
+		Args:           args,
+		Port:           c.Spec.Port,
+		Env:            env,
+		Probes:         c.Spec.Probes.WithDefaults(c.defaultProbes()),
+		Resources:      c.Spec.Resources,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "config", MountPath: cacheConfigDir, ReadOnly: true},
+		},
+		Volumes: []corev1.Volume{
+			{
+				Name: "config",
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: c.ConfigName()},
+					},
+				},
+			},
+		},
+	}
+}
+
+// cli is the command line client shipped in the cache image.
+func (c Cache) cli() string {
+	if c.Spec.Flavor == "valkey" {
+		return "valkey-cli"
+	}
+	return "redis-cli"
+}
+
+// defaultProbes ping the server with the CLI shipped in the cache image.
+func (c Cache) defaultProbes() ProbesSpec {
+	ping := &ExecProbe{Command: []string{c.cli(), "-p", strconv.Itoa(int(c.Spec.Port)), "ping"}}
+
+	return ProbesSpec{
+		Liveness:  &ProbeSpec{Exec: ping, InitialDelaySeconds: 5, PeriodSeconds: 10, TimeoutSeconds: 2},
+		Readiness: &ProbeSpec{Exec: ping, PeriodSeconds: 5, TimeoutSeconds: 2},
+	}
+}
+
+// config renders the server configuration file.
+func (c Cache) config() string {
+	lines := []string{fmt.Sprintf("port %d", c.Spec.Port)}
+	if c.Spec.MaxMemory != "" {
+		lines = append(lines, "maxmemory "+c.Spec.MaxMemory)
+	}
+	if c.Spec.EvictionPolicy != "" {
+		lines = append(lines, "maxmemory-policy "+c.Spec.EvictionPolicy)
+	}
+	if c.Spec.Persistence.IsEnabled() {
+		lines = append(lines, "dir "+cacheDataDir, "appendonly yes")
+	} else {
+		// Nothing would survive a restart anyway, skip the snapshots.
+		lines = append(lines, `save ""`, "appendonly no")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (c Cache) configChecksum() string {
+	sum := sha256.Sum256([]byte(c.config()))
+	return hex.EncodeToString(sum[:])
+}
+
+// ConfigMap returns the ConfigMap holding the server configuration.
+func (c Cache) ConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.Identifier(), Kind: "ConfigMap"},
+		ObjectMeta: objectMeta(c.ConfigName(), c.Namespace, nil),
+		Data:       map[string]string{cacheConfigFile: c.config()},
+	}
+}
+
+// PasswordSecret returns the generated password Secret, or nil when auth is disabled or uses an existing Secret.
+// The password already in the cluster is reused, see GeneratedSecret.
+func (c Cache) PasswordSecret(lookup SecretLookup) (*corev1.Secret, error) {
+	if !c.Spec.Auth.IsEnabled() || c.Spec.Auth.ExistingSecret != "" {
+		return nil, nil
+	}
+	return GeneratedSecret(lookup, c.Namespace, c.PasswordSecretName(), c.Spec.Auth.SecretKey)
+}
+
+// Service returns the Service exposing the cache to the application.
+func (c Cache) Service() *corev1.Service {
+	return Service{
+		Name:      c.Name(),
+		Namespace: c.Namespace,
+		Port:      c.Spec.Port,
+	}.Build()
+}
+
+// HeadlessServiceName is the Service governing the StatefulSet used when persistence is enabled.
+func (c Cache) HeadlessServiceName() string {
+	return fmt.Sprintf("%s-cache-headless", c.AppName)
+}
+
+// HeadlessService returns the headless Service governing the StatefulSet, or nil when persistence is disabled. The
+// application keeps connecting through Service.
+func (c Cache) HeadlessService() *corev1.Service {
+	if !c.Spec.Persistence.IsEnabled() {
+		return nil
+	}
+	return Service{
+		Name:      c.HeadlessServiceName(),
+		Namespace: c.Namespace,
+		Selector:  AppSelector(c.Name()),
+		Port:      c.Spec.Port,
+		Headless:  true,
+	}.Build()
+}
+
+// Env returns the env vars applications use to reach the cache.
+func (c Cache) Env() []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{Name: "CACHE_HOST", Value: c.Name()},
+		{Name: "CACHE_PORT", Value: fmt.Sprintf("%d", c.Spec.Port)},
+	}
+	if c.Spec.Auth.IsEnabled() {
+		env = append(env, corev1.EnvVar{Name: "CACHE_PASSWORD", ValueFrom: SecretKeyRef(c.PasswordSecretName(), c.Spec.Auth.SecretKey)})
+	}
+	return env
+}
+
+// Image returns the cache image: the override when set, otherwise the official image of the flavor and version.
+// The digest, when set, pins the image.
+func (c Cache) Image() string {
+	image := c.Spec.Image
+	if image == "" {
+		flavor, _ := lookupCacheFlavor(c.Spec.Flavor)
+		image = fmt.Sprintf("%s:%s", flavor.repository, c.Spec.Version)
+	}
+	if c.Spec.Digest != "" {
+		image += "@" + c.Spec.Digest
+	}
+	return image
+}
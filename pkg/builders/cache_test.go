@@ -0,0 +1,71 @@
+package builders
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupCacheFlavor(t *testing.T) {
+	for name, want := range map[string]string{
+		"":         "redis",
+		"redis":    "redis",
+		"Valkey":   "valkey",
+		"VALKEY":   "valkey",
+		"memcache": "",
+	} {
+		flavor, ok := lookupCacheFlavor(name)
+		if ok != (want != "") || flavor.name != want {
+			t.Errorf("lookupCacheFlavor(%q) = %q, %v, want %q", name, flavor.name, ok, want)
+		}
+	}
+}
+
+func TestCacheSpecValidate(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	for _, test := range []struct {
+		name    string
+		spec    CacheSpec
+		wantErr string
+	}{
+		{name: "default"},
+		{name: "redis version", spec: CacheSpec{Version: "7.4"}},
+		{name: "valkey version", spec: CacheSpec{Flavor: "valkey", Version: "8.1"}},
+		{name: "unknown flavor", spec: CacheSpec{Flavor: "memcached"}, wantErr: `spec.cache.flavor "memcached" is not supported, expected one of redis, valkey`},
+		{name: "unknown version", spec: CacheSpec{Version: "6.2"}, wantErr: `spec.cache.version "6.2" is not supported for redis, expected one of 7.2, 7.4, 8.0`},
+		{name: "version of another flavor", spec: CacheSpec{Flavor: "valkey", Version: "7.4"}, wantErr: `spec.cache.version "7.4" is not supported for valkey`},
+		{name: "any version with an image", spec: CacheSpec{Version: "6.2", Image: "mirror.example.com/redis:6.2"}},
+		{name: "digest", spec: CacheSpec{Digest: digest}},
+		{name: "invalid digest", spec: CacheSpec{Digest: "sha256:abc"}, wantErr: `spec.cache.digest "sha256:abc" must look like sha256:<64 hex characters>`},
+		{name: "digest twice", spec: CacheSpec{Image: "redis@" + digest, Digest: digest}, wantErr: "spec.cache.digest cannot be set when spec.cache.image already pins a digest"},
+		{name: "max memory", spec: CacheSpec{MaxMemory: "256mb"}},
+		{name: "invalid max memory", spec: CacheSpec{MaxMemory: "256MiB"}, wantErr: `spec.cache.maxMemory "256MiB" must be a number of bytes`},
+		{name: "invalid eviction policy", spec: CacheSpec{EvictionPolicy: "lru"}, wantErr: `spec.cache.evictionPolicy "lru" must be one of noeviction`},
+		{name: "invalid persistence size", spec: CacheSpec{Persistence: &CachePersistenceSpec{Enabled: true, Size: "lots"}}, wantErr: `spec.cache.persistence.size: "lots" is not a valid quantity`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			checkError(t, test.spec.Validate("spec.cache"), test.wantErr)
+		})
+	}
+}
+
+func TestCacheImage(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("0", 64)
+	for _, test := range []struct {
+		name string
+		spec CacheSpec
+		want string
+	}{
+		{name: "default", want: "docker.io/redis:7.2"},
+		{name: "redis version", spec: CacheSpec{Version: "8.0"}, want: "docker.io/redis:8.0"},
+		{name: "valkey", spec: CacheSpec{Flavor: "Valkey"}, want: "docker.io/valkey/valkey:8.1"},
+		{name: "digest", spec: CacheSpec{Flavor: "valkey", Version: "7.2", Digest: digest}, want: "docker.io/valkey/valkey:7.2@" + digest},
+		{name: "image", spec: CacheSpec{Version: "7.4", Image: "mirror.example.com/redis:7.4-alpine"}, want: "mirror.example.com/redis:7.4-alpine"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			test.spec.SetDefaults()
+			if got := (Cache{AppName: "shop", Spec: test.spec}).Image(); got != test.want {
+				t.Errorf("Image() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
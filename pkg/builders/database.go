@@ -0,0 +1,185 @@
+package builders
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DatabaseSpec holds the CloudNativePG (CNPG) inputs shared by the database-backed scaffolds.
+type DatabaseSpec struct {
+	ClusterName     string `json:"clusterName"`
+	DatabaseName    string `json:"databaseName"`
+	Instances       int32  `json:"instances,omitempty" Default:"1"`
+	StorageSize     string `json:"storageSize,omitempty" Default:"\"10Gi\""`
+	PostgresVersion string `json:"postgresVersion,omitempty" Default:"\"16\""`
+	// CredentialsSecret optionally names a kubernetes.io/basic-auth Secret holding the application user.
+	// When empty the "<clusterName>-app" Secret generated by CNPG is used. Its username and password are substituted
+	// into DATABASE_URL verbatim, so they must not need percent-encoding.
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+	// Resources are applied to every PostgreSQL instance through the Cluster spec.resources.
+	Resources *ResourcesSpec `json:"resources,omitempty"`
+	// Backup archives the WALs and takes scheduled base backups to an object store.
+	Backup *DatabaseBackupSpec `json:"backup,omitempty"`
+	// Bootstrap creates the cluster from a backup or another cluster instead of an empty database.
+	Bootstrap *DatabaseBootstrapSpec `json:"bootstrap,omitempty"`
+	// Pooler routes the application through PgBouncer instead of connecting to the cluster directly.
+	Pooler *DatabasePoolerSpec `json:"pooler,omitempty"`
+	// Synchronous makes commits wait for replicas, trading write latency for zero data loss on failover.
+	Synchronous *DatabaseSynchronousSpec `json:"synchronous,omitempty"`
+	// Roles are additional PostgreSQL roles managed by CNPG.
+	Roles []DatabaseRoleSpec `json:"roles,omitempty"`
+	// Parameters are postgresql.conf settings, e.g. max_connections or shared_buffers.
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// SharedPreloadLibraries are loaded at server start, e.g. "pg_cron" or "timescaledb".
+	SharedPreloadLibraries []string `json:"sharedPreloadLibraries,omitempty"`
+	// PostInitApplicationSQL runs once in the application database after it is created.
+	PostInitApplicationSQL []string `json:"postInitApplicationSQL,omitempty"`
+	// Extensions are created in the application database, e.g. "pgcrypto" or "vector".
+	Extensions []string `json:"extensions,omitempty"`
+}
+
+// Validate checks the required fields. Path is the location of the spec in the custom resource, e.g. "spec.database".
+func (spec DatabaseSpec) Validate(path string) error {
+	if spec.ClusterName == "" {
+		return fmt.Errorf("%s.clusterName is required", path)
+	}
+	if spec.DatabaseName == "" {
+		return fmt.Errorf("%s.databaseName is required", path)
+	}
+	if spec.Instances < 0 {
+		return fmt.Errorf("%s.instances cannot be negative", path)
+	}
+	if err := spec.Synchronous.validate(path+".synchronous", max(spec.Instances, 1)); err != nil {
+		return err
+	}
+	if err := spec.Resources.Validate(path + ".resources"); err != nil {
+		return err
+	}
+	if err := spec.validateRoles(path + ".roles"); err != nil {
+		return err
+	}
+	if err := spec.validatePostgresql(path); err != nil {
+		return err
+	}
+	if err := spec.Backup.Validate(path + ".backup"); err != nil {
+		return err
+	}
+	if err := spec.Pooler.Validate(path + ".pooler"); err != nil {
+		return err
+	}
+	return spec.Bootstrap.Validate(path+".bootstrap", spec)
+}
+
+// SetDefaults fills in the optional fields.
+func (spec *DatabaseSpec) SetDefaults() {
+	if spec.Instances <= 0 {
+		spec.Instances = 1
+	}
+	if spec.StorageSize == "" {
+		spec.StorageSize = "10Gi"
+	}
+	if spec.PostgresVersion == "" {
+		spec.PostgresVersion = "16"
+	}
+	spec.Backup.SetDefaults()
+	spec.Pooler.SetDefaults()
+	spec.Bootstrap.SetDefaults(*spec)
+}
+
+// Database builds the CNPG cluster and the env vars applications use to reach it.
+type Database struct {
+	Namespace string
+	Spec      DatabaseSpec
+}
+
+// Host is the read-write Service the application connects to: the PgBouncer pooler when enabled, otherwise the
+// Service created by CNPG for the primary.
+func (db Database) Host() string {
+	if db.Spec.Pooler.IsEnabled() {
+		return db.PoolerName("rw")
+	}
+	return fmt.Sprintf("%s-rw", db.Spec.ClusterName)
+}
+
+// CredentialsSecret is the Secret holding the application user credentials.
+func (db Database) CredentialsSecret() string {
+	if db.Spec.CredentialsSecret != "" {
+		return db.Spec.CredentialsSecret
+	}
+	return fmt.Sprintf("%s-app", db.Spec.ClusterName)
+}
+
+// Cluster returns the postgresql.cnpg.io/v1 Cluster.
+func (db Database) Cluster() *unstructured.Unstructured {
+	bootstrap, externalClusters := db.bootstrap()
+
+	spec := map[string]interface{}{
+		"instances": db.Spec.Instances,
+		"imageName": fmt.Sprintf("ghcr.io/cloudnative-pg/postgresql:%s", db.Spec.PostgresVersion),
+		"storage": map[string]interface{}{
+			"size": db.Spec.StorageSize,
+		},
+		"bootstrap": bootstrap,
+	}
+	if externalClusters != nil {
+		spec["externalClusters"] = externalClusters
+	}
+	if resources := db.Spec.Resources.object(); resources != nil {
+		spec["resources"] = resources
+	}
+	if db.Spec.Backup.IsEnabled() {
+		spec["plugins"] = []interface{}{db.barmanPlugin()}
+	}
+	if postgresql := db.postgresql(); postgresql != nil {
+		spec["postgresql"] = postgresql
+	}
+	if managed := db.managed(); managed != nil {
+		spec["managed"] = managed
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "postgresql.cnpg.io/v1",
+			"kind":       "Cluster",
+			"metadata": map[string]interface{}{
+				"name":      db.Spec.ClusterName,
+				"namespace": db.Namespace,
+			},
+			"spec": spec,
+		},
+	}
+}
+
+// Env returns the connection env vars for the cluster. Credentials are read from the application
+// Secret via secretKeyRef so they never appear in the rendered Deployment. Clusters with replicas also get
+// DATABASE_READ_HOST and DATABASE_READ_URL, to send read-only queries to the replicas.
+func (db Database) Env() []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{Name: "DATABASE_HOST", Value: db.Host()},
+		{Name: "DATABASE_NAME", Value: db.Spec.DatabaseName},
+		{Name: "DATABASE_PORT", Value: "5432"},
+		{Name: "DATABASE_USER", ValueFrom: SecretKeyRef(db.CredentialsSecret(), "username")},
+		{Name: "DATABASE_PASSWORD", ValueFrom: SecretKeyRef(db.CredentialsSecret(), "password")},
+		// Kubernetes expands $(VAR) references to variables declared earlier in the list.
+		{Name: "DATABASE_URL", Value: "postgresql://$(DATABASE_USER):$(DATABASE_PASSWORD)@$(DATABASE_HOST):$(DATABASE_PORT)/$(DATABASE_NAME)"},
+	}
+	if db.HasReplicas() {
+		env = append(env,
+			corev1.EnvVar{Name: "DATABASE_READ_HOST", Value: db.ReadHost()},
+			corev1.EnvVar{Name: "DATABASE_READ_URL", Value: "postgresql://$(DATABASE_USER):$(DATABASE_PASSWORD)@$(DATABASE_READ_HOST):$(DATABASE_PORT)/$(DATABASE_NAME)"},
+		)
+	}
+	return env
+}
+
+// SecretKeyRef returns an env var source reading key from the named Secret.
+func SecretKeyRef(name, key string) *corev1.EnvVarSource {
+	return &corev1.EnvVarSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			Key:                  key,
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package builders
+
+import (
+	"cmp"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Deployment describes a single-container Deployment.
+type Deployment struct {
+	Name      string
+	Namespace string
+	// Selector identifies the pods owned by the Deployment. It defaults to {"app": Name}.
+	Selector map[string]string
+	// Labels are added to the Deployment and its pod template alongside the selector.
+	Labels map[string]string
+	// PodAnnotations are set on the pod template, e.g. a checksum rolling the pods when their configuration changes.
+	PodAnnotations map[string]string
+	Replicas       int32
+	// Autoscaling leaves spec.replicas unset when enabled so the ATC does not fight the HPA while fixing drift.
+	Autoscaling *AutoscalingSpec
+
+	// ContainerName defaults to Name.
+	ContainerName   string
+	Image           string
+	ImagePullPolicy corev1.PullPolicy
+	Args            []string
+	// PortName optionally names the container port so Services can target it by name.
+	PortName string
+	Port     int32
+	Env      []corev1.EnvVar
+	EnvFrom  []corev1.EnvFromSource
+	// Probes default their ports to Port.
+	Probes       *ProbesSpec
+	Resources    *ResourcesSpec
+	VolumeMounts []corev1.VolumeMount
+	Volumes      []corev1.Volume
+	// InitContainers run to completion before the container starts, e.g. to wait for database migrations.
+	InitContainers     []corev1.Container
+	ServiceAccountName string
+}
+
+// Build returns the apps/v1 Deployment.
+func (d Deployment) Build() *appsv1.Deployment {
+	var replicas *int32
+	if !d.Autoscaling.IsEnabled() {
+		replicas = &d.Replicas
+	}
+
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.Identifier(), Kind: "Deployment"},
+		ObjectMeta: objectMeta(d.Name, d.Namespace, d.labels()),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: replicas,
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType},
+			Selector: &metav1.LabelSelector{MatchLabels: d.selector()},
+			Template: d.podTemplate(),
+		},
+	}
+}
+
+func (d Deployment) selector() map[string]string {
+	if d.Selector == nil {
+		return AppSelector(d.Name)
+	}
+	return d.Selector
+}
+
+func (d Deployment) labels() map[string]string {
+	return mergeLabels(d.Labels, d.selector())
+}
+
+func (d Deployment) podTemplate() corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: d.labels(), Annotations: d.PodAnnotations},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: d.ServiceAccountName,
+			Volumes:            d.Volumes,
+			InitContainers:     d.InitContainers,
+			Containers:         []corev1.Container{d.container()},
+		},
+	}
+}
+
+func (d Deployment) container() corev1.Container {
+	container := corev1.Container{
+		Name:            cmp.Or(d.ContainerName, d.Name),
+		Image:           d.Image,
+		ImagePullPolicy: d.ImagePullPolicy,
+		Args:            d.Args,
+		Env:             d.Env,
+		EnvFrom:         d.EnvFrom,
+		Ports: []corev1.ContainerPort{
+			{Name: d.PortName, Protocol: corev1.ProtocolTCP, ContainerPort: d.Port},
+		},
+		Resources:    d.Resources.Build(),
+		VolumeMounts: d.VolumeMounts,
+	}
+	d.Probes.apply(&container, d.Port)
+	return container
+}
+
+// AppSelector is the selector used by the scaffolds to match the pods of a workload.
+func AppSelector(name string) map[string]string {
+	return map[string]string{"app": name}
+}
@@ -0,0 +1,210 @@
+package builders
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// EnvVar is a user supplied environment variable. It follows the shape of the Kubernetes container env, limited to
+// literal values and secretKeyRef, configMapKeyRef and fieldRef sources.
+type EnvVar struct {
+	Name      string        `json:"name"`
+	Value     string        `json:"value,omitempty"`
+	ValueFrom *EnvVarSource `json:"valueFrom,omitempty"`
+}
+
+// EnvVarSource reads the value of an EnvVar from a Secret, a ConfigMap or a pod field. Exactly one must be set.
+type EnvVarSource struct {
+	SecretKeyRef    *KeySelector   `json:"secretKeyRef,omitempty"`
+	ConfigMapKeyRef *KeySelector   `json:"configMapKeyRef,omitempty"`
+	FieldRef        *FieldSelector `json:"fieldRef,omitempty"`
+}
+
+// KeySelector selects a key of a Secret or ConfigMap in the namespace of the custom resource.
+type KeySelector struct {
+	Name     string `json:"name"`
+	Key      string `json:"key"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// FieldSelector selects a field of the pod, e.g. "metadata.name" or "status.podIP".
+type FieldSelector struct {
+	FieldPath string `json:"fieldPath"`
+}
+
+// EnvFromSource imports every key of a Secret or ConfigMap as environment variables. Exactly one must be set.
+type EnvFromSource struct {
+	// Prefix is prepended to every imported key.
+	Prefix       string     `json:"prefix,omitempty"`
+	SecretRef    *SourceRef `json:"secretRef,omitempty"`
+	ConfigMapRef *SourceRef `json:"configMapRef,omitempty"`
+}
+
+// SourceRef names the Secret or ConfigMap of an EnvFromSource.
+type SourceRef struct {
+	Name     string `json:"name"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// ValidateEnv checks the user supplied env at path. Variables cannot be declared twice, nor collide with the injected
+// variables set by the flight itself.
+func ValidateEnv(path string, env []EnvVar, injected []corev1.EnvVar) error {
+	seen := map[string]bool{}
+	for _, variable := range injected {
+		seen[variable.Name] = true
+	}
+
+	for i, variable := range env {
+		item := fmt.Sprintf("%s[%d]", path, i)
+		if variable.Name == "" {
+			return fmt.Errorf("%s.name is required", item)
+		}
+		if strings.Contains(variable.Name, "=") {
+			return fmt.Errorf("%s.name %q cannot contain '='", item, variable.Name)
+		}
+		if seen[variable.Name] {
+			if isInjected(variable.Name, injected) {
+				return fmt.Errorf("%s.name %q is set by the template and cannot be overridden", item, variable.Name)
+			}
+			return fmt.Errorf("%s.name %q is declared more than once", item, variable.Name)
+		}
+		seen[variable.Name] = true
+
+		if variable.ValueFrom == nil {
+			continue
+		}
+		if variable.Value != "" {
+			return fmt.Errorf("%s cannot set both value and valueFrom", item)
+		}
+		if err := variable.ValueFrom.validate(item + ".valueFrom"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (source EnvVarSource) validate(path string) error {
+	sources := 0
+	for _, ref := range []struct {
+		name     string
+		selector *KeySelector
+	}{
+		{"secretKeyRef", source.SecretKeyRef},
+		{"configMapKeyRef", source.ConfigMapKeyRef},
+	} {
+		if ref.selector == nil {
+			continue
+		}
+		sources++
+		if ref.selector.Name == "" {
+			return fmt.Errorf("%s.%s.name is required", path, ref.name)
+		}
+		if ref.selector.Key == "" {
+			return fmt.Errorf("%s.%s.key is required", path, ref.name)
+		}
+	}
+	if source.FieldRef != nil {
+		sources++
+		if source.FieldRef.FieldPath == "" {
+			return fmt.Errorf("%s.fieldRef.fieldPath is required", path)
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("%s must set exactly one of secretKeyRef, configMapKeyRef or fieldRef", path)
+	}
+	return nil
+}
+
+// ValidateEnvFrom checks the user supplied envFrom at path.
+func ValidateEnvFrom(path string, envFrom []EnvFromSource) error {
+	for i, source := range envFrom {
+		item := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case (source.SecretRef == nil) == (source.ConfigMapRef == nil):
+			return fmt.Errorf("%s must set exactly one of secretRef or configMapRef", item)
+		case source.SecretRef != nil && source.SecretRef.Name == "":
+			return fmt.Errorf("%s.secretRef.name is required", item)
+		case source.ConfigMapRef != nil && source.ConfigMapRef.Name == "":
+			return fmt.Errorf("%s.configMapRef.name is required", item)
+		}
+	}
+	return nil
+}
+
+// Env returns the container env: the injected variables first, so user values may reference them with $(NAME),
+// followed by the user supplied ones.
+func Env(injected []corev1.EnvVar, env []EnvVar) []corev1.EnvVar {
+	out := append([]corev1.EnvVar{}, injected...)
+	for _, variable := range env {
+		out = append(out, variable.build())
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func (variable EnvVar) build() corev1.EnvVar {
+	out := corev1.EnvVar{Name: variable.Name, Value: variable.Value}
+	if source := variable.ValueFrom; source != nil {
+		switch {
+		case source.SecretKeyRef != nil:
+			out.ValueFrom = SecretKeyRef(source.SecretKeyRef.Name, source.SecretKeyRef.Key)
+			out.ValueFrom.SecretKeyRef.Optional = optional(source.SecretKeyRef.Optional)
+		case source.ConfigMapKeyRef != nil:
+			out.ValueFrom = &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: source.ConfigMapKeyRef.Name},
+					Key:                  source.ConfigMapKeyRef.Key,
+					Optional:             optional(source.ConfigMapKeyRef.Optional),
+				},
+			}
+		case source.FieldRef != nil:
+			out.ValueFrom = &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: source.FieldRef.FieldPath},
+			}
+		}
+	}
+	return out
+}
+
+// EnvFrom converts the user supplied envFrom into the container envFrom.
+func EnvFrom(envFrom []EnvFromSource) []corev1.EnvFromSource {
+	var out []corev1.EnvFromSource
+	for _, source := range envFrom {
+		converted := corev1.EnvFromSource{Prefix: source.Prefix}
+		if ref := source.SecretRef; ref != nil {
+			converted.SecretRef = &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+				Optional:             optional(ref.Optional),
+			}
+		}
+		if ref := source.ConfigMapRef; ref != nil {
+			converted.ConfigMapRef = &corev1.ConfigMapEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+				Optional:             optional(ref.Optional),
+			}
+		}
+		out = append(out, converted)
+	}
+	return out
+}
+
+func isInjected(name string, injected []corev1.EnvVar) bool {
+	for _, variable := range injected {
+		if variable.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// optional omits the field unless it is set, keeping the rendered objects minimal.
+func optional(value bool) *bool {
+	if !value {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,82 @@
+package builders
+
+import (
+	"cmp"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// HTTPRoutes returns the gateway.networking.k8s.io/v1 HTTPRoutes attached to the Gateway of the spec, one per group
+// of hosts serving the same paths, or nil unless the gateway provider is selected. The first one is named after the
+// application, the others get a numbered suffix.
+func (i Ingress) HTTPRoutes() []*unstructured.Unstructured {
+	if !i.Spec.IsGateway() {
+		return nil
+	}
+
+	gateway := i.Spec.Gateway
+	parent := map[string]interface{}{
+		"name": gateway.Name,
+	}
+	if gateway.Namespace != "" {
+		parent["namespace"] = gateway.Namespace
+	}
+	if gateway.SectionName != "" {
+		parent["sectionName"] = gateway.SectionName
+	}
+
+	var httpRoutes []*unstructured.Unstructured
+	for index, rule := range i.rules() {
+		var rules []interface{}
+		for _, path := range rule.paths {
+			rules = append(rules, i.httpRouteRule(path))
+		}
+
+		httpRoutes = append(httpRoutes, &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "gateway.networking.k8s.io/v1",
+				"kind":       "HTTPRoute",
+				"metadata": map[string]interface{}{
+					"name":      i.objectName(index),
+					"namespace": i.Namespace,
+				},
+				"spec": map[string]interface{}{
+					"parentRefs": []interface{}{parent},
+					"hostnames":  toInterfaces(rule.hosts),
+					"rules":      rules,
+				},
+			},
+		})
+	}
+	return httpRoutes
+}
+
+func (i Ingress) httpRouteRule(path ingressPath) map[string]interface{} {
+	pathType := "PathPrefix"
+	if path.pathType == PathTypeExact {
+		pathType = "Exact"
+	}
+	match := map[string]interface{}{
+		"path": map[string]interface{}{"type": pathType, "value": path.path},
+	}
+	if len(i.Spec.Headers) > 0 {
+		var headers []interface{}
+		for _, header := range i.Spec.Headers {
+			headers = append(headers, map[string]interface{}{
+				"type":  cmp.Or(header.Type, headerMatchExact),
+				"name":  header.Name,
+				"value": header.Value,
+			})
+		}
+		match["headers"] = headers
+	}
+
+	var backendRefs []interface{}
+	for _, service := range i.weightedServices(path) {
+		backendRefs = append(backendRefs, service)
+	}
+	return map[string]interface{}{
+		"matches":     []interface{}{match},
+		"backendRefs": backendRefs,
+	}
+}
@@ -0,0 +1,93 @@
+package builders
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yokecd/yoke/pkg/flight/wasi/k8s"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// DefaultBaseDomain is the baseDomain of the platform (see system/stolos-system.yml), used when neither the flight
+	// nor the StolosPlatform resource sets one.
+	DefaultBaseDomain = "stolos.dev"
+	// DefaultHostPattern publishes every application under its own subdomain of the namespace.
+	DefaultHostPattern = "{name}.{namespace}.{baseDomain}"
+)
+
+// stolosPlatform identifies the cluster-scoped resource holding the platform configuration.
+const (
+	stolosPlatformAPIVersion = "stolos.cloud/v1alpha"
+	stolosPlatformKind       = "StolosPlatform"
+	stolosPlatformName       = "stolos-platform"
+)
+
+// HostDefaults derives the hostnames left empty in the custom resources. Flights read it from their
+// AirwayInputs.yml, so every resource of a template shares it.
+type HostDefaults struct {
+	// BaseDomain overrides the baseDomain of the StolosPlatform resource.
+	BaseDomain string `json:"baseDomain,omitempty"`
+	// Pattern expands {name}, {namespace} and {baseDomain}. It defaults to DefaultHostPattern.
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Host returns the hostname of the application name in namespace. The base domain is, in order, the one of the
+// HostDefaults, the spec.baseDomain of the StolosPlatform read through lookup, then DefaultBaseDomain.
+func (h HostDefaults) Host(lookup ObjectLookup, name, namespace string) (string, error) {
+	baseDomain := h.BaseDomain
+	if baseDomain == "" {
+		platform, err := lookupStolosPlatform(lookup)
+		if err != nil {
+			return "", err
+		}
+		if platform != nil {
+			baseDomain, _, _ = unstructured.NestedString(platform.Object, "spec", "baseDomain")
+		}
+	}
+	if baseDomain == "" {
+		baseDomain = DefaultBaseDomain
+	}
+
+	pattern := h.Pattern
+	if pattern == "" {
+		pattern = DefaultHostPattern
+	}
+	host := strings.NewReplacer(
+		"{name}", name,
+		"{namespace}", namespace,
+		"{baseDomain}", baseDomain,
+	).Replace(pattern)
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+		return "", fmt.Errorf("host %q derived from the pattern %q is not a valid hostname", host, pattern)
+	}
+	return host, nil
+}
+
+// lookupStolosPlatform reads the StolosPlatform, or returns nil when the flight cannot see it: the platform is not
+// installed, its CRD is missing, or the Airway does not grant access to it through its resourceAccessMatchers.
+func lookupStolosPlatform(lookup ObjectLookup) (*unstructured.Unstructured, error) {
+	platform, err := lookupObject(lookup, stolosPlatformAPIVersion, stolosPlatformKind, "", stolosPlatformName)
+	if isPlatformUnavailable(err) {
+		return nil, nil
+	}
+	return platform, err
+}
+
+// isPlatformUnavailable recognizes the errors of k8s.Lookup inside the ATC, which only carry the message of a missing
+// kind, as well as the ones of client-go lookups.
+func isPlatformUnavailable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case k8s.IsErrForbidden(err), k8s.IsErrNotFound(err):
+		return true
+	case apierrors.IsForbidden(err), apierrors.IsNotFound(err), meta.IsNoMatchError(err):
+		return true
+	default:
+		return strings.Contains(err.Error(), "no matches for kind")
+	}
+}
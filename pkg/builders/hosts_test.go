@@ -0,0 +1,65 @@
+package builders
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// platformLookup serves a StolosPlatform with spec, or err when set.
+func platformLookup(spec map[string]interface{}, err error) ObjectLookup {
+	return func(apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
+		if err != nil {
+			return nil, err
+		}
+		if kind != stolosPlatformKind || name != stolosPlatformName {
+			return nil, nil
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       spec,
+		}}, nil
+	}
+}
+
+var errPlatformForbidden = apierrors.NewForbidden(schema.GroupResource{Group: "stolos.cloud", Resource: "stolosplatforms"}, stolosPlatformName, errors.New("access not granted"))
+
+func TestHostDefaultsHost(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		defaults HostDefaults
+		lookup   ObjectLookup
+		want     string
+		wantErr  string
+	}{
+		{name: "default", want: "shop.team.stolos.dev"},
+		{name: "platform", lookup: platformLookup(map[string]interface{}{"baseDomain": "apps.example.com"}, nil), want: "shop.team.apps.example.com"},
+		{name: "platform without baseDomain", lookup: platformLookup(map[string]interface{}{}, nil), want: "shop.team.stolos.dev"},
+		{name: "override", defaults: HostDefaults{BaseDomain: "example.org"}, lookup: platformLookup(map[string]interface{}{"baseDomain": "apps.example.com"}, nil), want: "shop.team.example.org"},
+		{name: "pattern", defaults: HostDefaults{Pattern: "{namespace}-{name}.{baseDomain}"}, want: "team-shop.stolos.dev"},
+		{name: "forbidden", lookup: platformLookup(nil, errPlatformForbidden), want: "shop.team.stolos.dev"},
+		{name: "missing kind", lookup: platformLookup(nil, errors.New(`no matches for kind "StolosPlatform" in version "stolos.cloud/v1alpha"`)), want: "shop.team.stolos.dev"},
+		{name: "lookup error", lookup: platformLookup(nil, errors.New("connection refused")), wantErr: "looking up StolosPlatform /stolos-platform: connection refused"},
+		{name: "invalid pattern", defaults: HostDefaults{Pattern: "{name}_{namespace}.{baseDomain}"}, wantErr: `host "shop_team.stolos.dev" derived from the pattern`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.defaults.Host(test.lookup, "shop", "team")
+			switch {
+			case test.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Host() error = %v, want %q", err, test.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("Host() error = %v", err)
+			case got != test.want:
+				t.Errorf("Host() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
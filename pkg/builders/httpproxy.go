@@ -0,0 +1,128 @@
+package builders
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// HTTPProxies returns the projectcontour.io/v1 HTTPProxies, one per host as Contour serves a single fqdn per
+// HTTPProxy, or nil unless the contour provider is selected. The first one is named after the application, the others
+// get a numbered suffix.
+func (i Ingress) HTTPProxies() []*unstructured.Unstructured {
+	if !i.Spec.IsContour() {
+		return nil
+	}
+
+	var proxies []*unstructured.Unstructured
+	for _, rule := range i.rules() {
+		for _, host := range rule.hosts {
+			var routes []interface{}
+			for _, path := range rule.paths {
+				routes = append(routes, i.httpProxyRoute(path))
+			}
+			virtualHost := map[string]interface{}{
+				"fqdn": host,
+			}
+			if secretName := i.SecretName(); secretName != "" {
+				virtualHost["tls"] = map[string]interface{}{"secretName": secretName}
+			}
+
+			proxies = append(proxies, &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "projectcontour.io/v1",
+					"kind":       "HTTPProxy",
+					"metadata": map[string]interface{}{
+						"name":      i.objectName(len(proxies)),
+						"namespace": i.Namespace,
+					},
+					"spec": map[string]interface{}{
+						"virtualhost": virtualHost,
+						"routes":      routes,
+					},
+				},
+			})
+		}
+	}
+	return proxies
+}
+
+func (i Ingress) httpProxyRoute(path ingressPath) map[string]interface{} {
+	var services []interface{}
+	for _, service := range i.weightedServices(path) {
+		services = append(services, service)
+	}
+	match := "prefix"
+	if path.pathType == PathTypeExact {
+		match = "exact"
+	}
+	conditions := []interface{}{
+		map[string]interface{}{match: path.path},
+	}
+	for _, header := range i.Spec.Headers {
+		match := "exact"
+		if header.Type == headerMatchRegex {
+			match = "regex"
+		}
+		conditions = append(conditions, map[string]interface{}{
+			"header": map[string]interface{}{"name": header.Name, match: header.Value},
+		})
+	}
+
+	proxyRoute := map[string]interface{}{
+		"conditions": conditions,
+		"services":   services,
+	}
+	if timeout := i.Spec.Timeout; timeout != nil {
+		policy := map[string]interface{}{}
+		if timeout.Response != "" {
+			policy["response"] = timeout.Response
+		}
+		if timeout.Idle != "" {
+			policy["idle"] = timeout.Idle
+		}
+		proxyRoute["timeoutPolicy"] = policy
+	}
+	if retry := i.Spec.Retry; retry != nil {
+		policy := map[string]interface{}{
+			"count": int64(max(retry.Count, 1)),
+		}
+		if retry.PerTryTimeout != "" {
+			policy["perTryTimeout"] = retry.PerTryTimeout
+		}
+		if len(retry.RetryOn) > 0 {
+			policy["retryOn"] = toInterfaces(retry.RetryOn)
+		}
+		proxyRoute["retryPolicy"] = policy
+	}
+	if i.Spec.Websockets {
+		proxyRoute["enableWebsockets"] = true
+	}
+	return proxyRoute
+}
+
+// Certificate returns the cert-manager.io/v1 Certificate of the HTTPProxies with TLS enabled, or nil otherwise.
+// cert-manager only reads the issuer annotation from Ingresses, so HTTPProxies request their certificate explicitly.
+func (i Ingress) Certificate() *unstructured.Unstructured {
+	if !i.Spec.IsContour() || !i.TLS.IsEnabled() {
+		return nil
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata": map[string]interface{}{
+				"name":      i.Name,
+				"namespace": i.Namespace,
+			},
+			"spec": map[string]interface{}{
+				"secretName": i.SecretName(),
+				"dnsNames":   toInterfaces(i.Hosts()),
+				"issuerRef": map[string]interface{}{
+					"group": "cert-manager.io",
+					"kind":  "ClusterIssuer",
+					"name":  i.clusterIssuer(),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,227 @@
+package builders
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Ingress describes the host/path rules routing to a Service: Host and Path, or Routes when set.
+type Ingress struct {
+	Name        string
+	Namespace   string
+	Host        string
+	Path        string
+	ServiceName string
+	// ServicePort defaults to 80, the port exposed by the scaffold Services.
+	ServicePort int32
+	// Aliases are served like Host, e.g. the apex domain next to "www.".
+	Aliases []string
+	// Routes replace the single Host/Path rule. Routes without a host are served on Host and its Aliases.
+	Routes []IngressRouteSpec
+	// PathServices route Prefix paths of Host and its Aliases to other Services, e.g. a backend API served on the
+	// same host as its frontend.
+	PathServices []IngressPathService
+	// ContainerPort is the application port, which routes reach through ServicePort. Routes to other container ports
+	// go through the Service port of the same number, see ServicePorts.
+	ContainerPort int32
+	// TLSSecretName enables TLS for every host with a Secret managed outside of the template.
+	TLSSecretName string
+	// TLS requests the certificate from cert-manager instead. Its secret name defaults to TLSSecretName, then to
+	// "<Name>-tls".
+	TLS *TLSSpec
+	// Spec selects the provider, see HTTPProxies for Contour and HTTPRoutes for the Gateway API.
+	Spec *IngressSpec
+}
+
+// IngressPathService is a Service of the namespace receiving a path.
+type IngressPathService struct {
+	Path        string
+	ServiceName string
+	// ServicePort defaults to 80.
+	ServicePort int32
+}
+
+// hostRule is a group of hosts serving the same paths.
+type hostRule struct {
+	hosts []string
+	paths []ingressPath
+}
+
+// ingressPath is a path of a hostRule and the Service port receiving it.
+type ingressPath struct {
+	path        string
+	pathType    string
+	serviceName string
+	port        int32
+}
+
+// rules groups the routes by host: Host and its Aliases first, with the PathServices, then the other hosts in order of
+// appearance. The paths have their defaults applied and their Port resolved to a Service port.
+func (i Ingress) rules() []hostRule {
+	routes := i.Routes
+	if len(routes) == 0 {
+		routes = []IngressRouteSpec{{Path: i.Path}}
+	}
+
+	rules := []hostRule{{hosts: append([]string{i.Host}, i.Aliases...)}}
+	for _, service := range i.PathServices {
+		rules[0].paths = append(rules[0].paths, ingressPath{
+			path:        service.Path,
+			pathType:    PathTypePrefix,
+			serviceName: service.ServiceName,
+			port:        cmp.Or(service.ServicePort, 80),
+		})
+	}
+	for _, route := range routes {
+		path := ingressPath{
+			path:        cmp.Or(route.Path, "/"),
+			pathType:    cmp.Or(route.PathType, PathTypePrefix),
+			serviceName: i.ServiceName,
+			port:        i.routeServicePort(route),
+		}
+
+		index := 0
+		if route.Host != "" && route.Host != i.Host {
+			index = slices.IndexFunc(rules, func(rule hostRule) bool { return rule.hosts[0] == route.Host })
+			if index < 0 {
+				rules = append(rules, hostRule{hosts: []string{route.Host}})
+				index = len(rules) - 1
+			}
+		}
+		rules[index].paths = append(rules[index].paths, path)
+	}
+
+	// Host has no path of its own when every route names another host.
+	if len(rules[0].paths) == 0 {
+		rules = rules[1:]
+	}
+	return rules
+}
+
+// Hosts lists every host served, e.g. the names the certificate covers.
+func (i Ingress) Hosts() []string {
+	var hosts []string
+	for _, rule := range i.rules() {
+		hosts = append(hosts, rule.hosts...)
+	}
+	return hosts
+}
+
+// ServicePorts are the container ports targeted by the routes next to ContainerPort. The Service exposes each of them
+// under the same port number.
+func (i Ingress) ServicePorts() []int32 {
+	var ports []int32
+	for _, route := range i.Routes {
+		if route.Port != 0 && route.Port != i.ContainerPort && !slices.Contains(ports, route.Port) {
+			ports = append(ports, route.Port)
+		}
+	}
+	return ports
+}
+
+func (i Ingress) routeServicePort(route IngressRouteSpec) int32 {
+	if route.Port == 0 || route.Port == i.ContainerPort {
+		return i.servicePort()
+	}
+	return route.Port
+}
+
+// Build returns the networking/v1 Ingress, or nil when another provider serves the application.
+func (i Ingress) Build() *networkingv1.Ingress {
+	if i.Spec.IsContour() || i.Spec.IsGateway() {
+		return nil
+	}
+
+	var rules []networkingv1.IngressRule
+	for _, rule := range i.rules() {
+		var paths []networkingv1.HTTPIngressPath
+		for _, path := range rule.paths {
+			pathType := networkingv1.PathType(path.pathType)
+			paths = append(paths, networkingv1.HTTPIngressPath{
+				Path:     path.path,
+				PathType: &pathType,
+				Backend: networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{
+						Name: path.serviceName,
+						Port: networkingv1.ServiceBackendPort{Number: path.port},
+					},
+				},
+			})
+		}
+		for _, host := range rule.hosts {
+			rules = append(rules, networkingv1.IngressRule{
+				Host: host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+				},
+			})
+		}
+	}
+
+	ingress := &networkingv1.Ingress{
+		TypeMeta:   metav1.TypeMeta{APIVersion: networkingv1.SchemeGroupVersion.Identifier(), Kind: "Ingress"},
+		ObjectMeta: objectMeta(i.Name, i.Namespace, nil),
+		Spec:       networkingv1.IngressSpec{Rules: rules},
+	}
+
+	if secretName := i.SecretName(); secretName != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{Hosts: i.Hosts(), SecretName: secretName},
+		}
+	}
+	if i.TLS.IsEnabled() {
+		ingress.Annotations = map[string]string{certManagerIssuerAnnotation: i.clusterIssuer()}
+	}
+
+	return ingress
+}
+
+// SecretName is the Secret holding the certificate of the hosts, or empty when they are served over plain HTTP.
+func (i Ingress) SecretName() string {
+	if !i.TLS.IsEnabled() {
+		return i.TLSSecretName
+	}
+	if i.TLS.SecretName != "" {
+		return i.TLS.SecretName
+	}
+	if i.TLSSecretName != "" {
+		return i.TLSSecretName
+	}
+	return fmt.Sprintf("%s-tls", i.Name)
+}
+
+// URL is the address of the first route of the application, e.g. "https://shop.example.com/api".
+func (i Ingress) URL() string {
+	scheme := "http"
+	if i.SecretName() != "" || (i.Spec.IsGateway() && i.Spec.Gateway.HTTPS) {
+		scheme = "https"
+	}
+	for _, rule := range i.rules() {
+		for _, path := range rule.paths {
+			if path.serviceName == i.ServiceName {
+				return fmt.Sprintf("%s://%s%s", scheme, rule.hosts[0], path.path)
+			}
+		}
+	}
+	return ""
+}
+
+// objectName names the HTTPProxy or HTTPRoute at index: the first one after the application, the others suffixed.
+func (i Ingress) objectName(index int) string {
+	if index == 0 {
+		return i.Name
+	}
+	return fmt.Sprintf("%s-%d", i.Name, index)
+}
+
+func (i Ingress) servicePort() int32 {
+	return cmp.Or(i.ServicePort, 80)
+}
+
+func (i Ingress) clusterIssuer() string {
+	return cmp.Or(i.TLS.ClusterIssuer, DefaultClusterIssuer)
+}
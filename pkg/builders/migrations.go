@@ -0,0 +1,168 @@
+package builders
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KubectlImage runs the init container waiting for the migrations Job unless MigrationsSpec.WaitImage replaces it, e.g.
+// with a mirror or the kubectl release of the cluster. Its entrypoint is kubectl.
+const KubectlImage = "registry.k8s.io/kubectl:v1.33.4"
+
+// DefaultMigrationsWaitTimeout bounds each wait of the application pods, the kubelet restarts the init container after
+// it so the pods keep waiting for a Job still running.
+const DefaultMigrationsWaitTimeout = "10m"
+
+// MigrationsSpec runs the database schema migrations in a Job the application pods wait for before starting.
+type MigrationsSpec struct {
+	Image string `json:"image"`
+	// Command overrides the image entrypoint, e.g. ["npm", "run", "migrate"].
+	Command []string `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	// WaitImage runs kubectl wait in the pods of the application. It defaults to KubectlImage.
+	WaitImage string `json:"waitImage,omitempty"`
+	// WaitTimeout bounds each wait, e.g. "30m" for migrations outlasting DefaultMigrationsWaitTimeout.
+	WaitTimeout string `json:"waitTimeout,omitempty"`
+}
+
+// IsEnabled reports whether migrations are configured. It is safe to call on a nil spec.
+func (spec *MigrationsSpec) IsEnabled() bool {
+	return spec != nil
+}
+
+// Validate checks the migrations settings when they are configured.
+func (spec *MigrationsSpec) Validate(path string) error {
+	if !spec.IsEnabled() {
+		return nil
+	}
+	if spec.Image == "" {
+		return fmt.Errorf("%s.image is required", path)
+	}
+	if spec.WaitTimeout != "" {
+		if timeout, err := time.ParseDuration(spec.WaitTimeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("%s.waitTimeout %q must be a positive duration, e.g. 30m", path, spec.WaitTimeout)
+		}
+	}
+	return nil
+}
+
+// Migrations describes the Job running the migrations of an application, and the RBAC letting the application pods
+// wait for it.
+type Migrations struct {
+	// Name is the application the migrations belong to.
+	Name      string
+	Namespace string
+	Spec      *MigrationsSpec
+	// Env and EnvFrom are usually the ones of the application, so migrations reach the database the same way.
+	Env     []corev1.EnvVar
+	EnvFrom []corev1.EnvFromSource
+}
+
+// JobName is suffixed with a hash of the migration container. Pod templates of Jobs are immutable, so a new image,
+// command or environment yields a new Job, and the previous one is pruned with the rest of the old release.
+func (m Migrations) JobName() string {
+	// Marshalling a container cannot fail.
+	container, _ := json.Marshal(m.container())
+	sum := sha256.Sum256(container)
+	return fmt.Sprintf("%s-migrate-%s", m.Name, hex.EncodeToString(sum[:])[:10])
+}
+
+// ServiceAccountName is the identity of the application pods, allowed to read the migrations Job.
+func (m Migrations) ServiceAccountName() string {
+	return fmt.Sprintf("%s-migrations", m.Name)
+}
+
+// selector labels the migration pods. It differs from the application selector so Services never route to them.
+func (m Migrations) selector() map[string]string {
+	return AppSelector(fmt.Sprintf("%s-migrations", m.Name))
+}
+
+func (m Migrations) container() corev1.Container {
+	return corev1.Container{
+		Name:    "migrations",
+		Image:   m.Spec.Image,
+		Command: m.Spec.Command,
+		Args:    m.Spec.Args,
+		Env:     m.Env,
+		EnvFrom: m.EnvFrom,
+	}
+}
+
+// Job returns the batch/v1 Job running the migrations.
+func (m Migrations) Job() *batchv1.Job {
+	return &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.Identifier(), Kind: "Job"},
+		ObjectMeta: objectMeta(m.JobName(), m.Namespace, m.selector()),
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: m.selector()},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers:    []corev1.Container{m.container()},
+				},
+			},
+		},
+	}
+}
+
+// WaitContainer returns the init container blocking the application pods until the Job completed. A failed Job keeps
+// the pods in Init until the timeout, after which the kubelet restarts the wait.
+func (m Migrations) WaitContainer() corev1.Container {
+	return corev1.Container{
+		Name:  "wait-for-migrations",
+		Image: cmp.Or(m.Spec.WaitImage, KubectlImage),
+		Args: []string{
+			"wait", "--for=condition=complete", "job/" + m.JobName(),
+			"--timeout=" + cmp.Or(m.Spec.WaitTimeout, DefaultMigrationsWaitTimeout),
+		},
+	}
+}
+
+// ServiceAccount returns the core/v1 ServiceAccount of the application pods.
+func (m Migrations) ServiceAccount() *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.Identifier(), Kind: "ServiceAccount"},
+		ObjectMeta: objectMeta(m.ServiceAccountName(), m.Namespace, m.selector()),
+	}
+}
+
+// Role returns the rbac/v1 Role reading the current migrations Job, which is all kubectl wait needs.
+func (m Migrations) Role() *rbacv1.Role {
+	return &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.Identifier(), Kind: "Role"},
+		ObjectMeta: objectMeta(m.ServiceAccountName(), m.Namespace, m.selector()),
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{batchv1.GroupName},
+				Resources:     []string{"jobs"},
+				ResourceNames: []string{m.JobName()},
+				Verbs:         []string{"get", "list", "watch"},
+			},
+		},
+	}
+}
+
+// RoleBinding returns the rbac/v1 RoleBinding granting the Role to the ServiceAccount.
+func (m Migrations) RoleBinding() *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.Identifier(), Kind: "RoleBinding"},
+		ObjectMeta: objectMeta(m.ServiceAccountName(), m.Namespace, m.selector()),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     m.ServiceAccountName(),
+		},
+		Subjects: []rbacv1.Subject{
+			{Kind: rbacv1.ServiceAccountKind, Name: m.ServiceAccountName(), Namespace: m.Namespace},
+		},
+	}
+}
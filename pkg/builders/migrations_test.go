@@ -0,0 +1,114 @@
+package builders
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestMigrationsSpecValidate(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		spec    *MigrationsSpec
+		wantErr string
+	}{
+		{name: "nil"},
+		{name: "image", spec: &MigrationsSpec{Image: "shop:1.0"}},
+		{name: "no image", spec: &MigrationsSpec{}, wantErr: "spec.migrations.image is required"},
+		{name: "wait timeout", spec: &MigrationsSpec{Image: "shop:1.0", WaitTimeout: "1h30m"}},
+		{name: "invalid wait timeout", spec: &MigrationsSpec{Image: "shop:1.0", WaitTimeout: "ten minutes"}, wantErr: `spec.migrations.waitTimeout "ten minutes" must be a positive duration`},
+		{name: "unitless wait timeout", spec: &MigrationsSpec{Image: "shop:1.0", WaitTimeout: "10"}, wantErr: `spec.migrations.waitTimeout "10" must be a positive duration`},
+		{name: "zero wait timeout", spec: &MigrationsSpec{Image: "shop:1.0", WaitTimeout: "0s"}, wantErr: `spec.migrations.waitTimeout "0s" must be a positive duration`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			checkError(t, test.spec.Validate("spec.migrations"), test.wantErr)
+		})
+	}
+}
+
+func TestMigrationsJobName(t *testing.T) {
+	base := Migrations{
+		Name:      "shop",
+		Namespace: "team",
+		Spec:      &MigrationsSpec{Image: "shop:1.0", Command: []string{"npm", "run", "migrate"}},
+		Env:       []corev1.EnvVar{{Name: "DATABASE_URL", Value: "postgres://db"}},
+	}
+	name := base.JobName()
+	if !regexp.MustCompile(`^shop-migrate-[0-9a-f]{10}$`).MatchString(name) {
+		t.Fatalf("JobName() = %q, want shop-migrate-<10 hex characters>", name)
+	}
+
+	for _, test := range []struct {
+		name    string
+		change  func(m *Migrations)
+		renamed bool
+	}{
+		{name: "unchanged", change: func(m *Migrations) {}},
+		{name: "wait settings", change: func(m *Migrations) { m.Spec.WaitImage, m.Spec.WaitTimeout = "kubectl:mirror", "30m" }},
+		{name: "image", change: func(m *Migrations) { m.Spec.Image = "shop:1.1" }, renamed: true},
+		{name: "command", change: func(m *Migrations) { m.Spec.Command = []string{"./migrate"} }, renamed: true},
+		{name: "args", change: func(m *Migrations) { m.Spec.Args = []string{"--dry-run"} }, renamed: true},
+		{name: "env", change: func(m *Migrations) { m.Env = []corev1.EnvVar{{Name: "DATABASE_URL", Value: "postgres://other"}} }, renamed: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			m := base
+			spec := *base.Spec
+			m.Spec = &spec
+			test.change(&m)
+			if got := m.JobName(); (got != name) != test.renamed {
+				t.Errorf("JobName() = %q after the change, was %q, want renamed = %v", got, name, test.renamed)
+			}
+		})
+	}
+}
+
+func TestMigrationsStatus(t *testing.T) {
+	m := Migrations{Name: "shop", Namespace: "team", Spec: &MigrationsSpec{Image: "shop:1.0"}}
+	job := func(conditions ...map[string]interface{}) ObjectLookup {
+		return func(apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
+			if kind != "Job" || namespace != m.Namespace || name != m.JobName() {
+				return nil, nil
+			}
+			var status []interface{}
+			for _, condition := range conditions {
+				status = append(status, condition)
+			}
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"status": map[string]interface{}{"conditions": status},
+			}}, nil
+		}
+	}
+
+	for _, test := range []struct {
+		name    string
+		lookup  ObjectLookup
+		want    string
+		wantErr string
+	}{
+		{name: "no lookup", want: MigrationsPending},
+		{name: "missing", lookup: func(string, string, string, string) (*unstructured.Unstructured, error) { return nil, nil }, want: MigrationsPending},
+		{name: "running", lookup: job(), want: MigrationsRunning},
+		{name: "complete", lookup: job(map[string]interface{}{"type": "Complete", "status": "True"}), want: MigrationsComplete},
+		{name: "failed", lookup: job(map[string]interface{}{"type": "Failed", "status": "True"}), want: MigrationsFailed},
+		{name: "not yet complete", lookup: job(map[string]interface{}{"type": "Complete", "status": "False"}), want: MigrationsRunning},
+		{
+			name: "lookup error",
+			lookup: func(string, string, string, string) (*unstructured.Unstructured, error) {
+				return nil, errors.New("timeout")
+			},
+			want:    MigrationsPending,
+			wantErr: "looking up Job team/" + m.JobName() + ": timeout",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			status, err := m.Status(test.lookup)
+			checkError(t, err, test.wantErr)
+			if status.Job != m.JobName() || status.Phase != test.want {
+				t.Errorf("Status() = %+v, want job %s in phase %s", status, m.JobName(), test.want)
+			}
+		})
+	}
+}
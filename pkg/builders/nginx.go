@@ -0,0 +1,212 @@
+package builders
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nginxConfigDir is where the nginx images include the server configurations from.
+const nginxConfigDir = "/etc/nginx/conf.d"
+
+// nginxConfigFile replaces the stock server configuration of the images.
+const nginxConfigFile = "default.conf"
+
+// nginxGzipTypes are the text responses compressed with gzip, text/html always is.
+var nginxGzipTypes = []string{
+	"text/css", "text/plain", "text/xml", "application/javascript", "application/json", "application/xml",
+	"image/svg+xml",
+}
+
+// nginxLocation is a path usable as an nginx prefix location without quoting.
+var nginxLocation = regexp.MustCompile(`^/[A-Za-z0-9._~!&*+,=:@%/-]*$`)
+
+// nginxExtension is a file extension without its dot, e.g. "js".
+var nginxExtension = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// NginxSpec configures the server of an nginx serving static files.
+type NginxSpec struct {
+	// SPA serves index.html for the paths matching no file, so the client-side router resolves the deep links.
+	SPA bool `json:"spa,omitempty"`
+	// Gzip compresses the text responses.
+	Gzip bool `json:"gzip,omitempty"`
+	// CacheControl sets the Cache-Control header of the files by extension.
+	CacheControl []NginxCacheControlSpec `json:"cacheControl,omitempty"`
+	// Headers are added to every response, e.g. X-Frame-Options or Content-Security-Policy.
+	Headers []NginxHeaderSpec `json:"headers,omitempty"`
+	// ProxyBackend forwards the backend path to the backend Service, so the site reaches it on its own origin.
+	ProxyBackend bool `json:"proxyBackend,omitempty"`
+}
+
+// NginxCacheControlSpec is the Cache-Control header of the files with one of the extensions, e.g.
+// {extensions: [js, css], value: "public, max-age=31536000, immutable"}.
+type NginxCacheControlSpec struct {
+	Extensions []string `json:"extensions"`
+	Value      string   `json:"value"`
+}
+
+// NginxHeaderSpec is a response header.
+type NginxHeaderSpec struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Validate checks the extensions and the headers, whose values end up quoted in the configuration, and the
+// backendPath forwarded with ProxyBackend.
+func (spec *NginxSpec) Validate(path, backendPath string) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.ProxyBackend && !nginxLocation.MatchString(backendPath) {
+		return fmt.Errorf("%s.proxyBackend cannot forward the backend path %q, it must be a plain absolute path", path, backendPath)
+	}
+	for i, cacheControl := range spec.CacheControl {
+		cacheControlPath := fmt.Sprintf("%s.cacheControl[%d]", path, i)
+		if len(cacheControl.Extensions) == 0 {
+			return fmt.Errorf("%s.extensions is required", cacheControlPath)
+		}
+		for j, extension := range cacheControl.Extensions {
+			if !nginxExtension.MatchString(extension) {
+				return fmt.Errorf("%s.extensions[%d] %q must be letters and digits, without the dot", cacheControlPath, j, extension)
+			}
+		}
+		if err := validateNginxValue(cacheControlPath+".value", cacheControl.Value); err != nil {
+			return err
+		}
+	}
+	for i, header := range spec.Headers {
+		headerPath := fmt.Sprintf("%s.headers[%d]", path, i)
+		switch {
+		case header.Name == "":
+			return fmt.Errorf("%s.name is required", headerPath)
+		case !httpHeaderName.MatchString(header.Name):
+			return fmt.Errorf("%s.name %q is not a valid header name", headerPath, header.Name)
+		}
+		if err := validateNginxValue(headerPath+".value", header.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNginxValue rejects what would escape the quoted string of the directive, and $: nginx expands variables in
+// add_header values, so the headers are sent verbatim rather than computed per request.
+func validateNginxValue(path, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s is required", path)
+	}
+	if strings.ContainsAny(value, "\"\\\r\n$") {
+		return fmt.Errorf("%s %q cannot contain quotes, backslashes, line breaks or $", path, value)
+	}
+	return nil
+}
+
+// NginxServer renders the server configuration of an nginx listening on port 80, in a ConfigMap mounted over the
+// stock one.
+type NginxServer struct {
+	// Name is the name of the ConfigMap.
+	Name      string
+	Namespace string
+	Spec      *NginxSpec
+	// Root is the directory of the site.
+	Root string
+	// BackendPath is forwarded to BackendURL, e.g. "http://shop:80", when the spec enables ProxyBackend.
+	BackendPath string
+	BackendURL  string
+}
+
+// Config renders the default.conf of the server.
+func (s NginxServer) Config() string {
+	spec := s.Spec
+	if spec == nil {
+		spec = &NginxSpec{}
+	}
+
+	// add_header directives of a location replace the ones of the server, so every location repeats them.
+	var headers []string
+	for _, header := range spec.Headers {
+		headers = append(headers, fmt.Sprintf(`add_header %s "%s" always;`, header.Name, header.Value))
+	}
+
+	var b strings.Builder
+	b.WriteString("server {\n")
+	b.WriteString("    listen 80;\n")
+	b.WriteString("    listen [::]:80;\n")
+	b.WriteString("    server_name _;\n")
+	fmt.Fprintf(&b, "    root %s;\n", s.Root)
+	b.WriteString("    index index.html;\n")
+	if spec.Gzip {
+		b.WriteString("\n")
+		b.WriteString("    gzip on;\n")
+		b.WriteString("    gzip_vary on;\n")
+		b.WriteString("    gzip_min_length 1024;\n")
+		fmt.Fprintf(&b, "    gzip_types %s;\n", strings.Join(nginxGzipTypes, " "))
+	}
+
+	fallback := "=404"
+	if spec.SPA {
+		fallback = "/index.html"
+	}
+	writeNginxLocation(&b, "/", headers, fmt.Sprintf("try_files $uri $uri/ %s;", fallback))
+	for _, cacheControl := range spec.CacheControl {
+		writeNginxLocation(&b, fmt.Sprintf(`~* \.(%s)$`, strings.Join(cacheControl.Extensions, "|")),
+			slices.Concat([]string{fmt.Sprintf(`add_header Cache-Control "%s" always;`, cacheControl.Value)}, headers),
+			"try_files $uri =404;",
+		)
+	}
+	if spec.ProxyBackend {
+		// ^~ keeps the regular expressions of CacheControl from matching the backend paths.
+		writeNginxLocation(&b, "^~ "+s.BackendPath, headers,
+			"proxy_pass "+s.BackendURL+";",
+			"proxy_set_header Host $host;",
+			"proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;",
+			"proxy_set_header X-Forwarded-Proto $scheme;",
+		)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func writeNginxLocation(b *strings.Builder, location string, headers []string, directives ...string) {
+	fmt.Fprintf(b, "\n    location %s {\n", location)
+	for _, directive := range slices.Concat(headers, directives) {
+		fmt.Fprintf(b, "        %s\n", directive)
+	}
+	b.WriteString("    }\n")
+}
+
+// ConfigChecksum rolls the pods when the configuration changes, nginx only reads it on startup.
+func (s NginxServer) ConfigChecksum() string {
+	sum := sha256.Sum256([]byte(s.Config()))
+	return hex.EncodeToString(sum[:])
+}
+
+// ConfigMap returns the ConfigMap holding default.conf.
+func (s NginxServer) ConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.Identifier(), Kind: "ConfigMap"},
+		ObjectMeta: objectMeta(s.Name, s.Namespace, nil),
+		Data:       map[string]string{nginxConfigFile: s.Config()},
+	}
+}
+
+// Volume is the ConfigMap volume of the configuration, see VolumeMount.
+func (s NginxServer) Volume() corev1.Volume {
+	return corev1.Volume{
+		Name: "nginx-config",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: s.Name}},
+		},
+	}
+}
+
+// VolumeMount mounts the configuration over the stock one.
+func (s NginxServer) VolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{Name: "nginx-config", MountPath: nginxConfigDir, ReadOnly: true}
+}
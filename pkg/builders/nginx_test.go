@@ -0,0 +1,66 @@
+package builders
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNginxSpecValidate(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		spec        *NginxSpec
+		backendPath string
+		wantErr     string
+	}{
+		{name: "nil"},
+		{
+			name: "valid",
+			spec: &NginxSpec{
+				CacheControl: []NginxCacheControlSpec{{Extensions: []string{"js", "css"}, Value: "public, max-age=31536000, immutable"}},
+				Headers:      []NginxHeaderSpec{{Name: "Content-Security-Policy", Value: "default-src 'self'"}},
+				ProxyBackend: true,
+			},
+			backendPath: "/api",
+		},
+		{name: "backend path", spec: &NginxSpec{ProxyBackend: true}, backendPath: "/api v1", wantErr: `spec.nginx.proxyBackend cannot forward the backend path "/api v1"`},
+		{name: "no extensions", spec: &NginxSpec{CacheControl: []NginxCacheControlSpec{{Value: "no-cache"}}}, wantErr: "spec.nginx.cacheControl[0].extensions is required"},
+		{name: "dotted extension", spec: &NginxSpec{CacheControl: []NginxCacheControlSpec{{Extensions: []string{".js"}, Value: "no-cache"}}}, wantErr: `spec.nginx.cacheControl[0].extensions[0] ".js" must be letters and digits`},
+		{name: "empty value", spec: &NginxSpec{CacheControl: []NginxCacheControlSpec{{Extensions: []string{"js"}}}}, wantErr: "spec.nginx.cacheControl[0].value is required"},
+		{name: "header without name", spec: &NginxSpec{Headers: []NginxHeaderSpec{{Value: "DENY"}}}, wantErr: "spec.nginx.headers[0].name is required"},
+		{name: "invalid header name", spec: &NginxSpec{Headers: []NginxHeaderSpec{{Name: "X-Frame Options", Value: "DENY"}}}, wantErr: `spec.nginx.headers[0].name "X-Frame Options" is not a valid header name`},
+		{name: "quote", spec: &NginxSpec{Headers: []NginxHeaderSpec{{Name: "X-Test", Value: `a"; return 200 "b`}}}, wantErr: "spec.nginx.headers[0].value"},
+		{name: "backslash", spec: &NginxSpec{Headers: []NginxHeaderSpec{{Name: "X-Test", Value: `a\`}}}, wantErr: "spec.nginx.headers[0].value"},
+		{name: "line break", spec: &NginxSpec{Headers: []NginxHeaderSpec{{Name: "X-Test", Value: "a\r\nSet-Cookie: b"}}}, wantErr: "spec.nginx.headers[0].value"},
+		{name: "variable", spec: &NginxSpec{Headers: []NginxHeaderSpec{{Name: "X-Host", Value: "$host"}}}, wantErr: `spec.nginx.headers[0].value "$host" cannot contain quotes, backslashes, line breaks or $`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			checkError(t, test.spec.Validate("spec.nginx", test.backendPath), test.wantErr)
+		})
+	}
+}
+
+func TestNginxServerConfigHeaders(t *testing.T) {
+	server := NginxServer{
+		Spec: &NginxSpec{
+			CacheControl: []NginxCacheControlSpec{{Extensions: []string{"js"}, Value: "no-cache"}},
+			Headers:      []NginxHeaderSpec{{Name: "X-Frame-Options", Value: "DENY"}},
+			ProxyBackend: true,
+		},
+		Root:        "/usr/share/nginx/html",
+		BackendPath: "/api",
+		BackendURL:  "http://shop:80",
+	}
+
+	// add_header is not inherited by the locations declaring their own, so each of them repeats the headers.
+	config := server.Config()
+	for _, location := range []string{"location / {", `location ~* \.(js)$ {`, "location ^~ /api {"} {
+		start := strings.Index(config, location)
+		if start < 0 {
+			t.Fatalf("Config() has no %q:\n%s", location, config)
+		}
+		block := config[start : start+strings.Index(config[start:], "}")]
+		if !strings.Contains(block, `add_header X-Frame-Options "DENY" always;`) {
+			t.Errorf("%s does not add the headers:\n%s", location, block)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package builders
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DatabasePoolerSpec puts PgBouncer, managed by CNPG Pooler resources, in front of the cluster.
+type DatabasePoolerSpec struct {
+	Enabled bool `json:"enabled"`
+	// Instances is the number of PgBouncer pods of each pooler.
+	Instances int32 `json:"instances,omitempty" Default:"1"`
+	// PoolMode is "session" or "transaction". Transaction pooling serves more clients but breaks session features
+	// such as prepared statements and advisory locks.
+	PoolMode string `json:"poolMode,omitempty" Default:"\"session\""`
+	// DefaultPoolSize is the number of server connections per user and database (PgBouncer default_pool_size).
+	DefaultPoolSize int32 `json:"defaultPoolSize,omitempty"`
+	// MaxClientConnections is the number of client connections accepted by each PgBouncer (max_client_conn).
+	MaxClientConnections int32 `json:"maxClientConnections,omitempty"`
+	// ReadOnly also emits a pooler in front of the replicas (the "-ro" service).
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// IsEnabled reports whether the poolers are emitted. It is safe to call on a nil spec.
+func (spec *DatabasePoolerSpec) IsEnabled() bool {
+	return spec != nil && spec.Enabled
+}
+
+// Validate checks the pooler settings when the pooler is enabled.
+func (spec *DatabasePoolerSpec) Validate(path string) error {
+	if !spec.IsEnabled() {
+		return nil
+	}
+	if spec.Instances < 0 {
+		return fmt.Errorf("%s.instances cannot be negative", path)
+	}
+	switch spec.PoolMode {
+	case "", "session", "transaction":
+	default:
+		return fmt.Errorf("%s.poolMode %q must be session or transaction", path, spec.PoolMode)
+	}
+	if spec.DefaultPoolSize < 0 {
+		return fmt.Errorf("%s.defaultPoolSize cannot be negative", path)
+	}
+	if spec.MaxClientConnections < 0 {
+		return fmt.Errorf("%s.maxClientConnections cannot be negative", path)
+	}
+	return nil
+}
+
+// SetDefaults fills in the optional fields when the pooler is enabled.
+func (spec *DatabasePoolerSpec) SetDefaults() {
+	if !spec.IsEnabled() {
+		return
+	}
+	if spec.Instances == 0 {
+		spec.Instances = 1
+	}
+	if spec.PoolMode == "" {
+		spec.PoolMode = "session"
+	}
+}
+
+// PoolerName is the Pooler, and the Service CNPG creates for it, of the given type ("rw" or "ro").
+func (db Database) PoolerName(poolerType string) string {
+	return fmt.Sprintf("%s-pooler-%s", db.Spec.ClusterName, poolerType)
+}
+
+// Poolers returns the postgresql.cnpg.io/v1 Poolers: "rw" when the pooler is enabled, plus "ro" when ReadOnly is set.
+func (db Database) Poolers() []*unstructured.Unstructured {
+	pooler := db.Spec.Pooler
+	if !pooler.IsEnabled() {
+		return nil
+	}
+
+	types := []string{"rw"}
+	if pooler.ReadOnly {
+		types = append(types, "ro")
+	}
+
+	var poolers []*unstructured.Unstructured
+	for _, poolerType := range types {
+		pgbouncer := map[string]interface{}{
+			"poolMode": pooler.PoolMode,
+		}
+		parameters := map[string]interface{}{}
+		if pooler.DefaultPoolSize > 0 {
+			parameters["default_pool_size"] = strconv.Itoa(int(pooler.DefaultPoolSize))
+		}
+		if pooler.MaxClientConnections > 0 {
+			parameters["max_client_conn"] = strconv.Itoa(int(pooler.MaxClientConnections))
+		}
+		if len(parameters) > 0 {
+			pgbouncer["parameters"] = parameters
+		}
+
+		poolers = append(poolers, &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "postgresql.cnpg.io/v1",
+				"kind":       "Pooler",
+				"metadata": map[string]interface{}{
+					"name":      db.PoolerName(poolerType),
+					"namespace": db.Namespace,
+				},
+				"spec": map[string]interface{}{
+					"cluster": map[string]interface{}{
+						"name": db.Spec.ClusterName,
+					},
+					"instances": pooler.Instances,
+					"type":      poolerType,
+					"pgbouncer": pgbouncer,
+				},
+			},
+		})
+	}
+	return poolers
+}
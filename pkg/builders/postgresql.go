@@ -0,0 +1,204 @@
+package builders
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DatabaseRoleSpec is a PostgreSQL role managed by CNPG next to the application owner, e.g. a read-only reporting user.
+type DatabaseRoleSpec struct {
+	Name string `json:"name"`
+	// Login allows the role to connect. Roles without it are groups other roles join through InRoles.
+	Login      bool `json:"login,omitempty"`
+	Superuser  bool `json:"superuser,omitempty"`
+	CreateDB   bool `json:"createdb,omitempty"`
+	CreateRole bool `json:"createrole,omitempty"`
+	// InRoles are the roles this role is a member of, e.g. "pg_read_all_data".
+	InRoles []string `json:"inRoles,omitempty"`
+	// ConnectionLimit caps the concurrent connections of the role. It is unlimited when zero.
+	ConnectionLimit int32 `json:"connectionLimit,omitempty"`
+	// PasswordSecret names a kubernetes.io/basic-auth Secret whose username matches Name. The role has no password
+	// when empty.
+	PasswordSecret string `json:"passwordSecret,omitempty"`
+}
+
+var (
+	postgresIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	postgresExtension  = regexp.MustCompile(`^[a-z_][a-z0-9_-]*$`)
+	postgresParameter  = regexp.MustCompile(`^[a-z_][a-z0-9_.]*$`)
+)
+
+// reservedRoles are created and managed by CNPG itself.
+var reservedRoles = []string{"postgres", "streaming_replica", "cnpg_pooler_pgbouncer"}
+
+// fixedParameters are set by CNPG and cannot be overridden through Parameters.
+var fixedParameters = []string{
+	"listen_addresses", "port", "unix_socket_directories", "hot_standby",
+	"archive_mode", "archive_command", "restore_command",
+	"ssl", "ssl_cert_file", "ssl_key_file", "ssl_ca_file",
+	"primary_conninfo", "primary_slot_name",
+}
+
+// validateRoles checks the managed roles. The application owner, named after the database, belongs to the bootstrap
+// and cannot be redeclared.
+func (spec DatabaseSpec) validateRoles(path string) error {
+	seen := map[string]bool{}
+	for i, role := range spec.Roles {
+		rolePath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case role.Name == "":
+			return fmt.Errorf("%s.name is required", rolePath)
+		case !postgresIdentifier.MatchString(role.Name):
+			return fmt.Errorf("%s.name %q must be a lowercase PostgreSQL identifier", rolePath, role.Name)
+		case slices.Contains(reservedRoles, role.Name) || strings.HasPrefix(role.Name, "pg_"):
+			return fmt.Errorf("%s.name %q is reserved", rolePath, role.Name)
+		case role.Name == spec.DatabaseName:
+			return fmt.Errorf("%s.name %q is the application owner, which is managed by the cluster bootstrap", rolePath, role.Name)
+		case seen[role.Name]:
+			return fmt.Errorf("%s.name %q is declared more than once", rolePath, role.Name)
+		}
+		seen[role.Name] = true
+		if role.ConnectionLimit < 0 {
+			return fmt.Errorf("%s.connectionLimit cannot be negative", rolePath)
+		}
+	}
+	return nil
+}
+
+// validatePostgresql checks the parameters, preloaded libraries, post-init SQL and extensions.
+func (spec DatabaseSpec) validatePostgresql(path string) error {
+	for _, name := range sortedKeys(spec.Parameters) {
+		switch {
+		case !postgresParameter.MatchString(name):
+			return fmt.Errorf("%s.parameters %q is not a valid PostgreSQL parameter name", path, name)
+		case name == "shared_preload_libraries":
+			return fmt.Errorf("%s.parameters %q must be set through %s.sharedPreloadLibraries", path, name, path)
+		case name == "synchronous_standby_names":
+			return fmt.Errorf("%s.parameters %q must be set through %s.synchronous", path, name, path)
+		case slices.Contains(fixedParameters, name):
+			return fmt.Errorf("%s.parameters %q is managed by CNPG and cannot be set", path, name)
+		}
+	}
+	for i, library := range spec.SharedPreloadLibraries {
+		if !postgresExtension.MatchString(library) {
+			return fmt.Errorf("%s.sharedPreloadLibraries[%d] %q is not a valid library name", path, i, library)
+		}
+	}
+	if len(spec.PostInitApplicationSQL) > 0 {
+		if bootstrap := spec.Bootstrap; bootstrap != nil && (bootstrap.Recovery != nil || bootstrap.PgBasebackup != nil) {
+			return fmt.Errorf("%s.postInitApplicationSQL only runs when the database is initialized empty or imported, not with bootstrap.recovery or bootstrap.pgBasebackup", path)
+		}
+	}
+	seen := map[string]bool{}
+	for i, extension := range spec.Extensions {
+		switch {
+		case !postgresExtension.MatchString(extension):
+			return fmt.Errorf("%s.extensions[%d] %q is not a valid extension name", path, i, extension)
+		case seen[extension]:
+			return fmt.Errorf("%s.extensions[%d] %q is declared more than once", path, i, extension)
+		}
+		seen[extension] = true
+	}
+	return nil
+}
+
+// postgresql returns the Cluster spec.postgresql, or nil when nothing is configured.
+func (db Database) postgresql() map[string]interface{} {
+	postgresql := map[string]interface{}{}
+	if db.Spec.Synchronous != nil {
+		postgresql["synchronous"] = db.Spec.Synchronous.object()
+	}
+	if len(db.Spec.Parameters) > 0 {
+		parameters := map[string]interface{}{}
+		for name, value := range db.Spec.Parameters {
+			parameters[name] = value
+		}
+		postgresql["parameters"] = parameters
+	}
+	if len(db.Spec.SharedPreloadLibraries) > 0 {
+		postgresql["shared_preload_libraries"] = toInterfaces(db.Spec.SharedPreloadLibraries)
+	}
+	if len(postgresql) == 0 {
+		return nil
+	}
+	return postgresql
+}
+
+// managed returns the Cluster spec.managed declaring the roles, or nil when there are none.
+func (db Database) managed() map[string]interface{} {
+	if len(db.Spec.Roles) == 0 {
+		return nil
+	}
+
+	var roles []interface{}
+	for _, role := range db.Spec.Roles {
+		object := map[string]interface{}{
+			"name":       role.Name,
+			"ensure":     "present",
+			"login":      role.Login,
+			"superuser":  role.Superuser,
+			"createdb":   role.CreateDB,
+			"createrole": role.CreateRole,
+		}
+		if len(role.InRoles) > 0 {
+			object["inRoles"] = toInterfaces(role.InRoles)
+		}
+		if role.ConnectionLimit > 0 {
+			object["connectionLimit"] = role.ConnectionLimit
+		}
+		if role.PasswordSecret != "" {
+			object["passwordSecret"] = map[string]interface{}{"name": role.PasswordSecret}
+		}
+		roles = append(roles, object)
+	}
+	return map[string]interface{}{"roles": roles}
+}
+
+// Extensions returns the postgresql.cnpg.io/v1 Database creating the extensions in the application database, or nil
+// when no extension is declared. Unlike postInitApplicationSQL, CNPG keeps reconciling it after the bootstrap.
+func (db Database) Extensions() *unstructured.Unstructured {
+	if len(db.Spec.Extensions) == 0 {
+		return nil
+	}
+
+	var extensions []interface{}
+	for _, extension := range db.Spec.Extensions {
+		extensions = append(extensions, map[string]interface{}{
+			"name":   extension,
+			"ensure": "present",
+		})
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "postgresql.cnpg.io/v1",
+			"kind":       "Database",
+			"metadata": map[string]interface{}{
+				"name":      db.Spec.ClusterName,
+				"namespace": db.Namespace,
+			},
+			"spec": map[string]interface{}{
+				"name":  db.Spec.DatabaseName,
+				"owner": db.Spec.DatabaseName,
+				"cluster": map[string]interface{}{
+					"name": db.Spec.ClusterName,
+				},
+				// The database belongs to the Cluster, deleting the template must not drop it first.
+				"databaseReclaimPolicy": "retain",
+				"extensions":            extensions,
+			},
+		},
+	}
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
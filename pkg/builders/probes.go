@@ -0,0 +1,176 @@
+package builders
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ProbesSpec configures the health checks of a container. Probes left empty are not set, unless the workload
+// provides built-in defaults (the cache and the nginx frontend do).
+type ProbesSpec struct {
+	Liveness  *ProbeSpec `json:"liveness,omitempty"`
+	Readiness *ProbeSpec `json:"readiness,omitempty"`
+	Startup   *ProbeSpec `json:"startup,omitempty"`
+}
+
+// ProbeSpec describes a single check. Exactly one of httpGet, tcpSocket or exec must be set.
+type ProbeSpec struct {
+	HTTPGet             *HTTPGetProbe   `json:"httpGet,omitempty"`
+	TCPSocket           *TCPSocketProbe `json:"tcpSocket,omitempty"`
+	Exec                *ExecProbe      `json:"exec,omitempty"`
+	InitialDelaySeconds int32           `json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int32           `json:"periodSeconds,omitempty"`
+	TimeoutSeconds      int32           `json:"timeoutSeconds,omitempty"`
+	SuccessThreshold    int32           `json:"successThreshold,omitempty"`
+	FailureThreshold    int32           `json:"failureThreshold,omitempty"`
+}
+
+// HTTPGetProbe checks that an HTTP GET on Path returns a 2xx or 3xx status.
+type HTTPGetProbe struct {
+	Path string `json:"path"`
+	// Port defaults to the container port.
+	Port int32 `json:"port,omitempty"`
+}
+
+// TCPSocketProbe checks that a TCP connection can be opened.
+type TCPSocketProbe struct {
+	// Port defaults to the container port.
+	Port int32 `json:"port,omitempty"`
+}
+
+// ExecProbe runs Command inside the container and succeeds when it exits with 0.
+type ExecProbe struct {
+	Command []string `json:"command"`
+}
+
+// Validate checks every configured probe. It is safe to call on a nil spec.
+func (spec *ProbesSpec) Validate(path string) error {
+	if spec == nil {
+		return nil
+	}
+	for _, probe := range []struct {
+		name string
+		spec *ProbeSpec
+	}{
+		{"liveness", spec.Liveness},
+		{"readiness", spec.Readiness},
+		{"startup", spec.Startup},
+	} {
+		if probe.spec == nil {
+			continue
+		}
+		if err := probe.spec.validate(path + "." + probe.name); err != nil {
+			return err
+		}
+		// Kubernetes rejects liveness and startup probes requiring more than one success.
+		if probe.name != "readiness" && probe.spec.SuccessThreshold > 1 {
+			return fmt.Errorf("%s.%s.successThreshold must be 1", path, probe.name)
+		}
+	}
+	return nil
+}
+
+func (spec ProbeSpec) validate(path string) error {
+	handlers := 0
+	if spec.HTTPGet != nil {
+		handlers++
+		if len(spec.HTTPGet.Path) == 0 || spec.HTTPGet.Path[0] != '/' {
+			return fmt.Errorf("%s.httpGet.path must start with /", path)
+		}
+	}
+	if spec.TCPSocket != nil {
+		handlers++
+	}
+	if spec.Exec != nil {
+		handlers++
+		if len(spec.Exec.Command) == 0 {
+			return fmt.Errorf("%s.exec.command is required", path)
+		}
+	}
+	if handlers != 1 {
+		return fmt.Errorf("%s must set exactly one of httpGet, tcpSocket or exec", path)
+	}
+	for _, field := range []struct {
+		name  string
+		value int32
+	}{
+		{"initialDelaySeconds", spec.InitialDelaySeconds},
+		{"periodSeconds", spec.PeriodSeconds},
+		{"timeoutSeconds", spec.TimeoutSeconds},
+		{"successThreshold", spec.SuccessThreshold},
+		{"failureThreshold", spec.FailureThreshold},
+	} {
+		if field.value < 0 {
+			return fmt.Errorf("%s.%s cannot be negative", path, field.name)
+		}
+	}
+	return nil
+}
+
+// WithDefaults returns a copy of spec where every unset probe is taken from defaults. It is safe to call on a nil spec.
+func (spec *ProbesSpec) WithDefaults(defaults ProbesSpec) *ProbesSpec {
+	merged := defaults
+	if spec == nil {
+		return &merged
+	}
+	if spec.Liveness != nil {
+		merged.Liveness = spec.Liveness
+	}
+	if spec.Readiness != nil {
+		merged.Readiness = spec.Readiness
+	}
+	if spec.Startup != nil {
+		merged.Startup = spec.Startup
+	}
+	return &merged
+}
+
+// apply sets the probes on container. Ports default to containerPort.
+func (spec *ProbesSpec) apply(container *corev1.Container, containerPort int32) {
+	if spec == nil {
+		return
+	}
+	container.LivenessProbe = spec.Liveness.build(containerPort)
+	container.ReadinessProbe = spec.Readiness.build(containerPort)
+	container.StartupProbe = spec.Startup.build(containerPort)
+}
+
+func (spec *ProbeSpec) build(containerPort int32) *corev1.Probe {
+	if spec == nil {
+		return nil
+	}
+
+	port := func(port int32) intstr.IntOrString {
+		if port == 0 {
+			port = containerPort
+		}
+		return intstr.FromInt32(port)
+	}
+
+	probe := &corev1.Probe{
+		InitialDelaySeconds: spec.InitialDelaySeconds,
+		PeriodSeconds:       spec.PeriodSeconds,
+		TimeoutSeconds:      spec.TimeoutSeconds,
+		SuccessThreshold:    spec.SuccessThreshold,
+		FailureThreshold:    spec.FailureThreshold,
+	}
+	switch {
+	case spec.HTTPGet != nil:
+		probe.HTTPGet = &corev1.HTTPGetAction{Path: spec.HTTPGet.Path, Port: port(spec.HTTPGet.Port)}
+	case spec.TCPSocket != nil:
+		probe.TCPSocket = &corev1.TCPSocketAction{Port: port(spec.TCPSocket.Port)}
+	case spec.Exec != nil:
+		probe.Exec = &corev1.ExecAction{Command: spec.Exec.Command}
+	}
+	return probe
+}
+
+// HTTPProbes returns liveness and readiness probes performing an HTTP GET on path, used as the nginx frontend defaults.
+func HTTPProbes(path string) ProbesSpec {
+	return ProbesSpec{
+		Liveness:  &ProbeSpec{HTTPGet: &HTTPGetProbe{Path: path}, PeriodSeconds: 10},
+		Readiness: &ProbeSpec{HTTPGet: &HTTPGetProbe{Path: path}, PeriodSeconds: 5},
+	}
+}
@@ -0,0 +1,78 @@
+package builders
+
+import (
+	"fmt"
+)
+
+// DatabaseSynchronousSpec enables quorum-based synchronous replication: a commit returns once Number replicas
+// confirmed it.
+type DatabaseSynchronousSpec struct {
+	Number int32 `json:"number"`
+	// DataDurability is "required", blocking writes while fewer than Number replicas are available, or "preferred",
+	// falling back to asynchronous replication instead.
+	DataDurability string `json:"dataDurability,omitempty" Default:"\"required\""`
+}
+
+// validate checks the synchronous replication settings against the number of instances of the cluster.
+func (spec *DatabaseSynchronousSpec) validate(path string, instances int32) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.Number < 1 {
+		return fmt.Errorf("%s.number must be at least 1", path)
+	}
+	switch spec.DataDurability {
+	case "", "required", "preferred":
+	default:
+		return fmt.Errorf("%s.dataDurability %q must be required or preferred", path, spec.DataDurability)
+	}
+	// The primary does not count, so Number replicas need Number + 1 instances.
+	if replicas := instances - 1; spec.Number > replicas {
+		return fmt.Errorf("%s.number (%d) requires at least %d instances, the cluster has %d", path, spec.Number, spec.Number+1, instances)
+	}
+	return nil
+}
+
+func (spec *DatabaseSynchronousSpec) object() map[string]interface{} {
+	dataDurability := spec.DataDurability
+	if dataDurability == "" {
+		dataDurability = "required"
+	}
+	return map[string]interface{}{
+		"method":         "any",
+		"number":         spec.Number,
+		"dataDurability": dataDurability,
+	}
+}
+
+// Warnings returns the settings that are accepted but likely to hurt availability. Path is the location of the spec
+// in the custom resource, e.g. "spec.database".
+func (spec DatabaseSpec) Warnings(path string) []string {
+	instances := max(spec.Instances, 1)
+
+	var warnings []string
+	if instances > 1 && instances%2 == 0 {
+		warnings = append(warnings, fmt.Sprintf("%s.instances is %d, an odd number of instances is recommended so a majority of them survives the loss of one", path, instances))
+	}
+	if sync := spec.Synchronous; sync != nil && sync.DataDurability != "preferred" && sync.Number == instances-1 {
+		warnings = append(warnings, fmt.Sprintf("%s.synchronous waits for every replica, writes block whenever one of them is unavailable (e.g. during a rolling update); add an instance or set dataDurability to preferred", path))
+	}
+	if spec.Pooler.IsEnabled() && spec.Pooler.ReadOnly && instances == 1 {
+		warnings = append(warnings, fmt.Sprintf("%s.pooler.readOnly has no replica to route to with a single instance", path))
+	}
+	return warnings
+}
+
+// HasReplicas reports whether the cluster runs read-only replicas next to the primary.
+func (db Database) HasReplicas() bool {
+	return db.Spec.Instances > 1
+}
+
+// ReadHost is the read-only Service balancing over the replicas: the read-only PgBouncer pooler when enabled,
+// otherwise the Service created by CNPG.
+func (db Database) ReadHost() string {
+	if db.Spec.Pooler.IsEnabled() && db.Spec.Pooler.ReadOnly {
+		return db.PoolerName("ro")
+	}
+	return fmt.Sprintf("%s-ro", db.Spec.ClusterName)
+}
@@ -0,0 +1,107 @@
+package builders
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ResourcesSpec holds the compute resources of a container as Kubernetes quantity strings, e.g. {"cpu": "250m"}.
+type ResourcesSpec struct {
+	Requests map[string]string `json:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
+}
+
+// Validate checks that every quantity parses and that no request exceeds its limit. It is safe to call on a nil spec.
+func (spec *ResourcesSpec) Validate(path string) error {
+	if spec == nil {
+		return nil
+	}
+	requests, err := parseResourceList(path+".requests", spec.Requests)
+	if err != nil {
+		return err
+	}
+	limits, err := parseResourceList(path+".limits", spec.Limits)
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(spec.Requests) {
+		request := requests[corev1.ResourceName(name)]
+		limit, ok := limits[corev1.ResourceName(name)]
+		if ok && request.Cmp(limit) > 0 {
+			return fmt.Errorf("%s.requests.%s (%s) cannot exceed %s.limits.%s (%s)", path, name, spec.Requests[name], path, name, spec.Limits[name])
+		}
+	}
+	return nil
+}
+
+// Build returns the container resource requirements. Validate must have been called first.
+func (spec *ResourcesSpec) Build() corev1.ResourceRequirements {
+	if spec == nil {
+		return corev1.ResourceRequirements{}
+	}
+	// Errors were reported by Validate.
+	requests, _ := parseResourceList("", spec.Requests)
+	limits, _ := parseResourceList("", spec.Limits)
+	return corev1.ResourceRequirements{Requests: requests, Limits: limits}
+}
+
+// object returns the resources in the shape expected by unstructured objects, or nil when none are set.
+func (spec *ResourcesSpec) object() map[string]interface{} {
+	if spec == nil {
+		return nil
+	}
+	object := map[string]interface{}{}
+	for key, list := range map[string]map[string]string{"requests": spec.Requests, "limits": spec.Limits} {
+		if len(list) == 0 {
+			continue
+		}
+		values := map[string]interface{}{}
+		for name, quantity := range list {
+			values[name] = quantity
+		}
+		object[key] = values
+	}
+	if len(object) == 0 {
+		return nil
+	}
+	return object
+}
+
+func parseResourceList(path string, list map[string]string) (corev1.ResourceList, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+	parsed := corev1.ResourceList{}
+	for _, name := range sortedKeys(list) {
+		// Extended resources such as nvidia.com/gpu are domain-prefixed.
+		switch corev1.ResourceName(name) {
+		case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+		default:
+			if !strings.Contains(name, "/") {
+				return nil, fmt.Errorf("%s.%s is not a supported resource (expected cpu, memory, ephemeral-storage or a domain-prefixed extended resource)", path, name)
+			}
+		}
+		quantity, err := resource.ParseQuantity(list[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %q is not a valid quantity", path, name, list[name])
+		}
+		if quantity.Sign() < 0 {
+			return nil, fmt.Errorf("%s.%s cannot be negative", path, name)
+		}
+		parsed[corev1.ResourceName(name)] = quantity
+	}
+	return parsed, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,356 @@
+package builders
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// IngressProviderIngress exposes the application with a networking.k8s.io/v1 Ingress.
+	IngressProviderIngress = "ingress"
+	// IngressProviderContour exposes the application with a projectcontour.io/v1 HTTPProxy.
+	IngressProviderContour = "contour"
+	// IngressProviderGateway exposes the application with a gateway.networking.k8s.io/v1 HTTPRoute.
+	IngressProviderGateway = "gateway"
+)
+
+// IngressSpec selects how an application is exposed. The timeouts, retries and websockets are Contour features;
+// weighted services and header matches are available with both the contour and gateway providers.
+type IngressSpec struct {
+	// Provider is "ingress", "contour" or "gateway".
+	Provider string `json:"provider,omitempty" Default:"\"ingress\""`
+	// Gateway is the parent of the HTTPRoute, required by the gateway provider.
+	Gateway    *IngressGatewaySpec `json:"gateway,omitempty"`
+	Timeout    *IngressTimeoutSpec `json:"timeout,omitempty"`
+	Retry      *IngressRetrySpec   `json:"retry,omitempty"`
+	Websockets bool                `json:"websockets,omitempty"`
+	// Services receive a share of the requests next to the application, e.g. a canary release. The application keeps
+	// the remaining percentage.
+	Services []IngressServiceSpec `json:"services,omitempty"`
+	// Headers restrict the route to the requests carrying all of them.
+	Headers []IngressHeaderMatchSpec `json:"headers,omitempty"`
+}
+
+// IngressGatewaySpec references the Gateway the HTTPRoute attaches to. Its listeners terminate TLS, so the template
+// tls settings do not apply.
+type IngressGatewaySpec struct {
+	Name string `json:"name"`
+	// Namespace defaults to the namespace of the application. The Gateway must allow routes from it.
+	Namespace string `json:"namespace,omitempty"`
+	// SectionName selects a single listener of the Gateway, e.g. "https".
+	SectionName string `json:"sectionName,omitempty"`
+	// HTTPS reports that the listener terminates TLS, so the published URL uses https.
+	HTTPS bool `json:"https,omitempty"`
+}
+
+// IngressTimeoutSpec bounds the requests. Durations use the Go syntax, e.g. "30s", or "infinity" to disable them.
+type IngressTimeoutSpec struct {
+	// Response is how long Envoy waits for the whole response of the application.
+	Response string `json:"response,omitempty"`
+	// Idle closes the connections without activity for that long.
+	Idle string `json:"idle,omitempty"`
+}
+
+// IngressRetrySpec retries the failed requests.
+type IngressRetrySpec struct {
+	Count         int32  `json:"count,omitempty" Default:"1"`
+	PerTryTimeout string `json:"perTryTimeout,omitempty"`
+	// RetryOn lists the Envoy retry conditions, e.g. "5xx" (the default), "gateway-error" or "reset".
+	RetryOn []string `json:"retryOn,omitempty"`
+}
+
+// IngressServiceSpec is a Service of the namespace receiving Weight percent of the requests.
+type IngressServiceSpec struct {
+	Name   string `json:"name"`
+	Port   int32  `json:"port,omitempty" Default:"80"`
+	Weight int32  `json:"weight"`
+}
+
+// IngressRouteSpec is a host and path served by the application, e.g. a versioned API prefix or another domain.
+type IngressRouteSpec struct {
+	// Host defaults to the host of the application, which its aliases serve as well.
+	Host string `json:"host,omitempty"`
+	Path string `json:"path,omitempty" Default:"\"/\""`
+	// PathType is "Prefix", "Exact" or, with the ingress provider only, "ImplementationSpecific".
+	PathType string `json:"pathType,omitempty" Default:"\"Prefix\""`
+	// Port is the container port receiving the requests. It defaults to the port of the application, other ports are
+	// added to its Service.
+	Port int32 `json:"port,omitempty"`
+}
+
+// IngressHeaderMatchSpec matches a request header, e.g. {name: X-Canary, value: "true"}.
+type IngressHeaderMatchSpec struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Type is "Exact" or "RegularExpression".
+	Type string `json:"type,omitempty" Default:"\"Exact\""`
+}
+
+const (
+	// PathTypePrefix matches the path and everything below it.
+	PathTypePrefix = "Prefix"
+	// PathTypeExact matches the path only.
+	PathTypeExact = "Exact"
+	// PathTypeImplementationSpecific leaves the matching to the Ingress controller.
+	PathTypeImplementationSpecific = "ImplementationSpecific"
+)
+
+const (
+	headerMatchExact = "Exact"
+	headerMatchRegex = "RegularExpression"
+)
+
+// contourRetryConditions are the retryOn values accepted by Contour.
+var contourRetryConditions = []string{
+	"5xx", "gateway-error", "reset", "connect-failure", "retriable-4xx", "refused-stream",
+	"retriable-status-codes", "retriable-headers",
+	"cancelled", "deadline-exceeded", "internal", "resource-exhausted", "unavailable",
+}
+
+// httpHeaderName is the token grammar of RFC 7230 header names.
+var httpHeaderName = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+\\-.^_`|~]+$")
+
+// IsContour reports whether Contour serves the application. It is safe to call on a nil spec.
+func (spec *IngressSpec) IsContour() bool {
+	return spec != nil && spec.Provider == IngressProviderContour
+}
+
+// IsGateway reports whether a Gateway serves the application. It is safe to call on a nil spec.
+func (spec *IngressSpec) IsGateway() bool {
+	return spec != nil && spec.Provider == IngressProviderGateway
+}
+
+// Validate checks the provider and its options.
+func (spec *IngressSpec) Validate(path string) error {
+	if spec == nil {
+		return nil
+	}
+	switch spec.Provider {
+	case "", IngressProviderIngress:
+		switch {
+		case len(spec.Services) > 0:
+			return fmt.Errorf("%s.services requires %s.provider contour or gateway", path, path)
+		case len(spec.Headers) > 0:
+			return fmt.Errorf("%s.headers requires %s.provider contour or gateway", path, path)
+		}
+	case IngressProviderContour, IngressProviderGateway:
+	default:
+		return fmt.Errorf("%s.provider %q must be ingress, contour or gateway", path, spec.Provider)
+	}
+	if spec.Provider != IngressProviderContour {
+		switch {
+		case spec.Timeout != nil:
+			return fmt.Errorf("%s.timeout requires %s.provider contour", path, path)
+		case spec.Retry != nil:
+			return fmt.Errorf("%s.retry requires %s.provider contour", path, path)
+		case spec.Websockets:
+			return fmt.Errorf("%s.websockets requires %s.provider contour", path, path)
+		}
+	}
+	if spec.Provider == IngressProviderGateway {
+		if err := spec.Gateway.validate(path + ".gateway"); err != nil {
+			return err
+		}
+	} else if spec.Gateway != nil {
+		return fmt.Errorf("%s.gateway requires %s.provider gateway", path, path)
+	}
+
+	if timeout := spec.Timeout; timeout != nil {
+		if err := validateContourDuration(path+".timeout.response", timeout.Response); err != nil {
+			return err
+		}
+		if err := validateContourDuration(path+".timeout.idle", timeout.Idle); err != nil {
+			return err
+		}
+	}
+	if retry := spec.Retry; retry != nil {
+		if retry.Count < 0 {
+			return fmt.Errorf("%s.retry.count cannot be negative", path)
+		}
+		if err := validateContourDuration(path+".retry.perTryTimeout", retry.PerTryTimeout); err != nil {
+			return err
+		}
+		for i, condition := range retry.RetryOn {
+			if !slices.Contains(contourRetryConditions, condition) {
+				return fmt.Errorf("%s.retry.retryOn[%d] %q is not a Contour retry condition", path, i, condition)
+			}
+		}
+	}
+
+	var total int32
+	seen := map[string]bool{}
+	for i, service := range spec.Services {
+		servicePath := fmt.Sprintf("%s.services[%d]", path, i)
+		switch {
+		case service.Name == "":
+			return fmt.Errorf("%s.name is required", servicePath)
+		case len(validation.IsDNS1035Label(service.Name)) > 0:
+			return fmt.Errorf("%s.name %q is not a valid Service name", servicePath, service.Name)
+		case seen[service.Name]:
+			return fmt.Errorf("%s.name %q is declared more than once", servicePath, service.Name)
+		case service.Port < 0 || service.Port > 65535:
+			return fmt.Errorf("%s.port must be between 1 and 65535", servicePath)
+		case service.Weight < 0:
+			return fmt.Errorf("%s.weight cannot be negative", servicePath)
+		}
+		seen[service.Name] = true
+		total += service.Weight
+	}
+	if total > 100 {
+		return fmt.Errorf("%s.services weights add up to %d, they cannot exceed 100", path, total)
+	}
+
+	for i, header := range spec.Headers {
+		headerPath := fmt.Sprintf("%s.headers[%d]", path, i)
+		switch {
+		case header.Name == "":
+			return fmt.Errorf("%s.name is required", headerPath)
+		case !httpHeaderName.MatchString(header.Name):
+			return fmt.Errorf("%s.name %q is not a valid header name", headerPath, header.Name)
+		}
+		switch header.Type {
+		case "", headerMatchExact:
+		case headerMatchRegex:
+			if _, err := regexp.Compile(header.Value); err != nil {
+				return fmt.Errorf("%s.value %q is not a valid regular expression", headerPath, header.Value)
+			}
+		default:
+			return fmt.Errorf("%s.type %q must be Exact or RegularExpression", headerPath, header.Type)
+		}
+	}
+	return nil
+}
+
+func (spec *IngressGatewaySpec) validate(path string) error {
+	switch {
+	case spec == nil || spec.Name == "":
+		return fmt.Errorf("%s.name is required with the gateway provider", path)
+	case len(validation.IsDNS1123Subdomain(spec.Name)) > 0:
+		return fmt.Errorf("%s.name %q is not a valid Gateway name", path, spec.Name)
+	case spec.Namespace != "" && len(validation.IsDNS1123Label(spec.Namespace)) > 0:
+		return fmt.Errorf("%s.namespace %q is not a valid namespace", path, spec.Namespace)
+	case spec.SectionName != "" && len(validation.IsDNS1123Subdomain(spec.SectionName)) > 0:
+		return fmt.Errorf("%s.sectionName %q is not a valid listener name", path, spec.SectionName)
+	}
+	return nil
+}
+
+// ValidateTLS checks the tls and tlsSecretName settings found next to the spec, under path, against the provider: the
+// listeners of a Gateway terminate TLS themselves.
+func (spec *IngressSpec) ValidateTLS(path string, tls *TLSSpec, tlsSecretName string) error {
+	if !spec.IsGateway() {
+		return nil
+	}
+	if tls.IsEnabled() {
+		return fmt.Errorf("%s.tls is not supported with the gateway provider, the Gateway listener terminates TLS", path)
+	}
+	if tlsSecretName != "" {
+		return fmt.Errorf("%s.tlsSecretName is not supported with the gateway provider, the Gateway listener terminates TLS", path)
+	}
+	return nil
+}
+
+// ValidateRoutes checks the routes and aliases found next to the spec, under path. Routes to other container ports
+// than containerPort go through Service ports of the same number, which leaves out port 80, the application one.
+func (spec *IngressSpec) ValidateRoutes(path string, routes []IngressRouteSpec, aliases []string, containerPort int32) error {
+	for i, alias := range aliases {
+		switch {
+		case len(validation.IsDNS1123Subdomain(alias)) > 0:
+			return fmt.Errorf("%s.aliases[%d] %q is not a valid hostname", path, i, alias)
+		case slices.Contains(aliases[:i], alias):
+			return fmt.Errorf("%s.aliases[%d] %q is declared more than once", path, i, alias)
+		}
+	}
+
+	seen := map[IngressRouteSpec]bool{}
+	for i, route := range routes {
+		routePath := fmt.Sprintf("%s.routes[%d]", path, i)
+		switch {
+		case route.Host != "" && len(validation.IsDNS1123Subdomain(route.Host)) > 0:
+			return fmt.Errorf("%s.host %q is not a valid hostname", routePath, route.Host)
+		case slices.Contains(aliases, route.Host):
+			return fmt.Errorf("%s.host %q is an alias, aliases serve the routes of the host", routePath, route.Host)
+		case route.Path != "" && route.Path[0] != '/':
+			return fmt.Errorf("%s.path %q must start with /", routePath, route.Path)
+		case route.Port < 0 || route.Port > 65535:
+			return fmt.Errorf("%s.port must be between 1 and 65535", routePath)
+		case route.Port == 80 && route.Port != containerPort:
+			return fmt.Errorf("%s.port 80 is the Service port of the application, it cannot target another container port", routePath)
+		}
+		switch route.PathType {
+		case "", PathTypePrefix, PathTypeExact:
+		case PathTypeImplementationSpecific:
+			if spec.IsContour() || spec.IsGateway() {
+				return fmt.Errorf("%s.pathType %s requires %s.ingress.provider ingress", routePath, route.PathType, path)
+			}
+		default:
+			return fmt.Errorf("%s.pathType %q must be Prefix, Exact or ImplementationSpecific", routePath, route.PathType)
+		}
+
+		key := IngressRouteSpec{Host: route.Host, Path: cmp.Or(route.Path, "/"), PathType: cmp.Or(route.PathType, PathTypePrefix)}
+		if seen[key] {
+			return fmt.Errorf("%s matches the same requests as a previous route", routePath)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// SetDefaults fills in the provider, the Service ports and the header match types.
+func (spec *IngressSpec) SetDefaults() {
+	if spec == nil {
+		return
+	}
+	if spec.Provider == "" {
+		spec.Provider = IngressProviderIngress
+	}
+	for i := range spec.Services {
+		if spec.Services[i].Port == 0 {
+			spec.Services[i].Port = 80
+		}
+	}
+	for i := range spec.Headers {
+		if spec.Headers[i].Type == "" {
+			spec.Headers[i].Type = headerMatchExact
+		}
+	}
+}
+
+func validateContourDuration(path, value string) error {
+	if value == "" || value == "infinity" || value == "infinite" {
+		return nil
+	}
+	if _, err := time.ParseDuration(value); err != nil {
+		return fmt.Errorf("%s %q is not a duration, e.g. 30s or infinity", path, value)
+	}
+	return nil
+}
+
+// weightedServices returns the Services of a path with their port, and their weight when the traffic of the
+// application is split. Weights are relative for both Contour and the Gateway API, the application keeps the share
+// left by the others.
+func (i Ingress) weightedServices(path ingressPath) []map[string]interface{} {
+	services := []map[string]interface{}{
+		{"name": path.serviceName, "port": int64(path.port)},
+	}
+	if len(i.Spec.Services) == 0 || path.serviceName != i.ServiceName {
+		return services
+	}
+
+	weight := int64(100)
+	for _, service := range i.Spec.Services {
+		weight -= int64(service.Weight)
+		services = append(services, map[string]interface{}{
+			"name":   service.Name,
+			"port":   int64(service.Port),
+			"weight": int64(service.Weight),
+		})
+	}
+	services[0]["weight"] = weight
+	return services
+}
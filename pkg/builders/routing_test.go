@@ -0,0 +1,75 @@
+package builders
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIngressSpecValidateRoutes(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		spec    *IngressSpec
+		routes  []IngressRouteSpec
+		aliases []string
+		wantErr string
+	}{
+		{name: "none"},
+		{
+			name:    "valid",
+			routes:  []IngressRouteSpec{{Path: "/"}, {Host: "api.example.com", Path: "/v1", PathType: PathTypeExact}, {Path: "/metrics", Port: 9090}},
+			aliases: []string{"www.example.com"},
+		},
+		{name: "invalid alias", aliases: []string{"Example_com"}, wantErr: `spec.aliases[0] "Example_com" is not a valid hostname`},
+		{name: "duplicate alias", aliases: []string{"www.example.com", "www.example.com"}, wantErr: `spec.aliases[1] "www.example.com" is declared more than once`},
+		{name: "invalid host", routes: []IngressRouteSpec{{Host: "api..example.com"}}, wantErr: `spec.routes[0].host "api..example.com" is not a valid hostname`},
+		{name: "alias host", routes: []IngressRouteSpec{{Host: "www.example.com"}}, aliases: []string{"www.example.com"}, wantErr: "spec.routes[0].host \"www.example.com\" is an alias"},
+		{name: "relative path", routes: []IngressRouteSpec{{Path: "api"}}, wantErr: `spec.routes[0].path "api" must start with /`},
+		{name: "port out of range", routes: []IngressRouteSpec{{Port: 70000}}, wantErr: "spec.routes[0].port must be between 1 and 65535"},
+		{name: "port 80", routes: []IngressRouteSpec{{Port: 80}}, wantErr: "spec.routes[0].port 80 is the Service port of the application"},
+		{name: "unknown path type", routes: []IngressRouteSpec{{PathType: "Regex"}}, wantErr: `spec.routes[0].pathType "Regex" must be Prefix, Exact or ImplementationSpecific`},
+		{name: "implementation specific", routes: []IngressRouteSpec{{PathType: PathTypeImplementationSpecific}}},
+		{
+			name:    "implementation specific with contour",
+			spec:    &IngressSpec{Provider: IngressProviderContour},
+			routes:  []IngressRouteSpec{{PathType: PathTypeImplementationSpecific}},
+			wantErr: "spec.routes[0].pathType ImplementationSpecific requires spec.ingress.provider ingress",
+		},
+		{name: "duplicate defaults", routes: []IngressRouteSpec{{}, {Path: "/", PathType: PathTypePrefix}}, wantErr: "spec.routes[1] matches the same requests as a previous route"},
+		{name: "same path on another host", routes: []IngressRouteSpec{{Path: "/"}, {Host: "api.example.com", Path: "/"}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.spec.ValidateRoutes("spec", test.routes, test.aliases, 8080)
+			checkError(t, err, test.wantErr)
+		})
+	}
+}
+
+func TestHTTPHeaderName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"X-Canary":        true,
+		"content-type":    true,
+		"X_Custom.Header": true,
+		"":                false,
+		"X Canary":        false,
+		"X-Canary:":       false,
+		"X-Caña":          false,
+		"X-Canary\r\n":    false,
+	} {
+		if got := httpHeaderName.MatchString(name); got != want {
+			t.Errorf("httpHeaderName.MatchString(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// checkError fails the test unless err contains wantErr, or is nil when wantErr is empty.
+func checkError(t *testing.T, err error, wantErr string) {
+	t.Helper()
+	switch {
+	case wantErr == "" && err != nil:
+		t.Fatalf("unexpected error: %v", err)
+	case wantErr != "" && err == nil:
+		t.Fatalf("expected an error containing %q", wantErr)
+	case wantErr != "" && !strings.Contains(err.Error(), wantErr):
+		t.Fatalf("error = %q, want it to contain %q", err, wantErr)
+	}
+}
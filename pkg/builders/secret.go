@@ -0,0 +1,45 @@
+package builders
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SecretLookup reads an existing Secret from the cluster. It returns nil and no error when the Secret does not exist.
+// Flights implement it with k8s.Lookup, which only works when running as wasm, so tests pass a fake instead.
+type SecretLookup func(namespace, name string) (*corev1.Secret, error)
+
+// GeneratedSecret returns an Opaque Secret holding a random value under key.
+//
+// Flights are re-rendered on every change and drift check, so the value of the Secret already in the cluster is reused
+// when present. Otherwise, or when lookup is nil, a new value is generated.
+func GeneratedSecret(lookup SecretLookup, namespace, name, key string) (*corev1.Secret, error) {
+	var value []byte
+	if lookup != nil {
+		existing, err := lookup(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			value = existing.Data[key]
+		}
+	}
+	if len(value) == 0 {
+		random := make([]byte, 24)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		// URL-safe so the value can be passed on command lines and embedded in connection URLs as-is.
+		value = []byte(base64.RawURLEncoding.EncodeToString(random))
+	}
+
+	return &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.Identifier(), Kind: "Secret"},
+		ObjectMeta: objectMeta(name, namespace, nil),
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{key: value},
+	}, nil
+}
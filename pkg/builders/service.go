@@ -0,0 +1,75 @@
+package builders
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Service describes a Service in front of a workload, exposing Port and the AdditionalPorts.
+type Service struct {
+	Name      string
+	Namespace string
+	// Selector defaults to {"app": Name}. It is also used as the Service labels.
+	Selector map[string]string
+	PortName string
+	Port     int32
+	// TargetPort defaults to Port.
+	TargetPort intstr.IntOrString
+	// NodePort switches the Service to type NodePort when set.
+	NodePort int32
+	// AdditionalPorts are container ports exposed under the same number, named "port-<number>".
+	AdditionalPorts []int32
+	// Headless skips the cluster IP, e.g. for the Service governing a StatefulSet: its DNS name resolves to the pods.
+	Headless bool
+}
+
+// Build returns the core/v1 Service.
+func (s Service) Build() *corev1.Service {
+	selector := s.Selector
+	if selector == nil {
+		selector = AppSelector(s.Name)
+	}
+	targetPort := s.TargetPort
+	if targetPort == (intstr.IntOrString{}) {
+		targetPort = intstr.FromInt32(s.Port)
+	}
+	serviceType := corev1.ServiceTypeClusterIP
+	if s.NodePort > 0 {
+		serviceType = corev1.ServiceTypeNodePort
+	}
+
+	ports := []corev1.ServicePort{
+		{
+			Name:       s.PortName,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       s.Port,
+			TargetPort: targetPort,
+			NodePort:   s.NodePort,
+		},
+	}
+	for _, port := range s.AdditionalPorts {
+		ports = append(ports, corev1.ServicePort{
+			Name:       fmt.Sprintf("port-%d", port),
+			Protocol:   corev1.ProtocolTCP,
+			Port:       port,
+			TargetPort: intstr.FromInt32(port),
+		})
+	}
+
+	service := &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.Identifier(), Kind: "Service"},
+		ObjectMeta: objectMeta(s.Name, s.Namespace, selector),
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Selector: selector,
+			Ports:    ports,
+		},
+	}
+	if s.Headless {
+		service.Spec.ClusterIP = corev1.ClusterIPNone
+	}
+	return service
+}
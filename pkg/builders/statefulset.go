@@ -0,0 +1,34 @@
+package builders
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StatefulSet describes a single-container StatefulSet. The pod is configured through the embedded Deployment, whose
+// Autoscaling setting is ignored.
+type StatefulSet struct {
+	Deployment
+	// ServiceName is the Service governing the network identity of the pods.
+	ServiceName string
+	// VolumeClaimTemplates get one PersistentVolumeClaim per pod, kept when the pod is rescheduled.
+	VolumeClaimTemplates []corev1.PersistentVolumeClaim
+}
+
+// Build returns the apps/v1 StatefulSet.
+func (s StatefulSet) Build() *appsv1.StatefulSet {
+	replicas := s.Replicas
+
+	return &appsv1.StatefulSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.Identifier(), Kind: "StatefulSet"},
+		ObjectMeta: objectMeta(s.Name, s.Namespace, s.labels()),
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:             &replicas,
+			ServiceName:          s.ServiceName,
+			Selector:             &metav1.LabelSelector{MatchLabels: s.selector()},
+			Template:             s.podTemplate(),
+			VolumeClaimTemplates: s.VolumeClaimTemplates,
+		},
+	}
+}
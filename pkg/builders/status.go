@@ -0,0 +1,183 @@
+package builders
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/yokecd/yoke/pkg/flight"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ObjectLookup reads an object back from the cluster, e.g. through yoke's k8s.Lookup. It returns nil, nil when the
+// object does not exist.
+type ObjectLookup func(apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error)
+
+// ComponentStatus is the readiness of one component of a template, reported in the status of its custom resource.
+type ComponentStatus struct {
+	Ready bool `json:"ready"`
+	// Replicas reads "<ready>/<desired>", e.g. "2/3". It is empty until the component exists in the cluster.
+	Replicas string `json:"replicas,omitempty"`
+}
+
+// ConditionWarnings is the status condition listing the settings accepted with warnings, e.g. DatabaseSpec.Warnings.
+// The ATC keeps it next to its Ready condition.
+const ConditionWarnings = "Warnings"
+
+// WarningsConditions returns the status conditions reporting the warnings, none when there are no warnings. The
+// condition keeps its transition time from previous, the conditions of the rendered resource, while its status holds.
+func WarningsConditions(previous flight.Conditions, generation int64, now metav1.Time, warnings []string) flight.Conditions {
+	if len(warnings) == 0 {
+		return nil
+	}
+	condition := metav1.Condition{
+		Type:               ConditionWarnings,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		LastTransitionTime: now,
+		Reason:             "AcceptedWithWarnings",
+		Message:            strings.Join(warnings, "; "),
+	}
+	if i := slices.IndexFunc(previous, func(c metav1.Condition) bool { return c.Type == ConditionWarnings }); i >= 0 {
+		if previous[i].Status == condition.Status {
+			condition.LastTransitionTime = previous[i].LastTransitionTime
+		}
+	}
+	return flight.Conditions{condition}
+}
+
+// cnpgHealthyPhase is the status.phase of a CNPG Cluster serving all its instances.
+const cnpgHealthyPhase = "Cluster in healthy state"
+
+// Status reports the readiness of the deployed Deployment. It is not ready while the rollout of the current spec is
+// in progress. A nil lookup, e.g. in tests, reports a component that does not exist yet.
+func (d Deployment) Status(lookup ObjectLookup) (ComponentStatus, error) {
+	return workloadStatus(lookup, "Deployment", d.Namespace, d.Name)
+}
+
+// Status reports the readiness of the deployed StatefulSet, like Deployment.Status.
+func (s StatefulSet) Status(lookup ObjectLookup) (ComponentStatus, error) {
+	return workloadStatus(lookup, "StatefulSet", s.Namespace, s.Name)
+}
+
+// Status reports the readiness of the cache workload.
+func (c Cache) Status(lookup ObjectLookup) (ComponentStatus, error) {
+	kind := "Deployment"
+	if c.Spec.Persistence.IsEnabled() {
+		kind = "StatefulSet"
+	}
+	return workloadStatus(lookup, kind, c.Namespace, c.Name())
+}
+
+// Status reports the readiness of the CNPG Cluster: every instance is ready and CNPG considers the cluster healthy.
+func (db Database) Status(lookup ObjectLookup) (ComponentStatus, error) {
+	cluster, err := lookupObject(lookup, "postgresql.cnpg.io/v1", "Cluster", db.Namespace, db.Spec.ClusterName)
+	if err != nil || cluster == nil {
+		return ComponentStatus{}, err
+	}
+
+	desired := nestedInt(cluster, 1, "spec", "instances")
+	ready := nestedInt(cluster, 0, "status", "readyInstances")
+	phase, _, _ := unstructured.NestedString(cluster.Object, "status", "phase")
+	return ComponentStatus{
+		Ready:    ready >= desired && phase == cnpgHealthyPhase,
+		Replicas: fmt.Sprintf("%d/%d", ready, desired),
+	}, nil
+}
+
+// MigrationsStatus is the state of the migrations Job of the current release, reported in the status of the custom
+// resource.
+type MigrationsStatus struct {
+	Job string `json:"job"`
+	// Phase is Pending until the Job exists, then Running, Complete or Failed.
+	Phase string `json:"phase"`
+}
+
+// The phases of MigrationsStatus.
+const (
+	MigrationsPending  = "Pending"
+	MigrationsRunning  = "Running"
+	MigrationsComplete = "Complete"
+	MigrationsFailed   = "Failed"
+)
+
+// Status reports the state of the migrations Job, from its Complete and Failed conditions.
+func (m Migrations) Status(lookup ObjectLookup) (MigrationsStatus, error) {
+	status := MigrationsStatus{Job: m.JobName(), Phase: MigrationsPending}
+	job, err := lookupObject(lookup, batchv1.SchemeGroupVersion.Identifier(), "Job", m.Namespace, status.Job)
+	if err != nil || job == nil {
+		return status, err
+	}
+
+	status.Phase = MigrationsRunning
+	conditions, _, _ := unstructured.NestedSlice(job.Object, "status", "conditions")
+	for _, condition := range conditions {
+		condition, _ := condition.(map[string]interface{})
+		if condition["status"] != string(corev1.ConditionTrue) {
+			continue
+		}
+		switch condition["type"] {
+		case string(batchv1.JobComplete):
+			status.Phase = MigrationsComplete
+		case string(batchv1.JobFailed):
+			status.Phase = MigrationsFailed
+		}
+	}
+	return status, nil
+}
+
+// Endpoint is the in-cluster address of the cache, e.g. "shop-cache:6379".
+func (c Cache) Endpoint() string {
+	return fmt.Sprintf("%s:%d", c.Name(), c.Spec.Port)
+}
+
+// workloadStatus reads the status of an apps/v1 Deployment or StatefulSet, which share the fields involved.
+func workloadStatus(lookup ObjectLookup, kind, namespace, name string) (ComponentStatus, error) {
+	workload, err := lookupObject(lookup, appsv1.SchemeGroupVersion.Identifier(), kind, namespace, name)
+	if err != nil || workload == nil {
+		return ComponentStatus{}, err
+	}
+
+	desired := nestedInt(workload, 1, "spec", "replicas")
+	ready := nestedInt(workload, 0, "status", "readyReplicas")
+	updated := nestedInt(workload, 0, "status", "updatedReplicas")
+	observed := nestedInt(workload, 0, "status", "observedGeneration")
+	return ComponentStatus{
+		Ready:    observed >= workload.GetGeneration() && updated >= desired && ready >= desired,
+		Replicas: fmt.Sprintf("%d/%d", ready, desired),
+	}, nil
+}
+
+func lookupObject(lookup ObjectLookup, apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
+	if lookup == nil {
+		return nil, nil
+	}
+	object, err := lookup(apiVersion, kind, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("looking up %s %s/%s: %w", kind, namespace, name, err)
+	}
+	return object, nil
+}
+
+// nestedInt reads an integer field, decoded as int64 by the unstructured JSON decoder or float64 by encoding/json.
+func nestedInt(object *unstructured.Unstructured, fallback int64, fields ...string) int64 {
+	value, found, _ := unstructured.NestedFieldNoCopy(object.Object, fields...)
+	if !found {
+		return fallback
+	}
+	switch value := value.(type) {
+	case int64:
+		return value
+	case int32:
+		return int64(value)
+	case int:
+		return int64(value)
+	case float64:
+		return int64(value)
+	}
+	return fallback
+}
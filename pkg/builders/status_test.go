@@ -0,0 +1,49 @@
+package builders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yokecd/yoke/pkg/flight"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWarningsConditions(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	warnings := []string{"spec.database.instances: 1 instance has no replica", "spec.database.backup is disabled"}
+
+	for _, test := range []struct {
+		name     string
+		previous flight.Conditions
+		warnings []string
+		wantTime metav1.Time
+		wantNone bool
+	}{
+		{name: "no warnings", previous: flight.Conditions{{Type: ConditionWarnings, Status: metav1.ConditionTrue}}, wantNone: true},
+		{name: "new", warnings: warnings, wantTime: now},
+		{name: "kept", previous: flight.Conditions{{Type: "Ready", Status: metav1.ConditionFalse}, {Type: ConditionWarnings, Status: metav1.ConditionTrue, LastTransitionTime: earlier}}, warnings: warnings, wantTime: earlier},
+		{name: "transitioned", previous: flight.Conditions{{Type: ConditionWarnings, Status: metav1.ConditionFalse, LastTransitionTime: earlier}}, warnings: warnings, wantTime: now},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			conditions := WarningsConditions(test.previous, 3, now, test.warnings)
+			if test.wantNone {
+				if conditions != nil {
+					t.Fatalf("WarningsConditions() = %+v, want none", conditions)
+				}
+				return
+			}
+			want := metav1.Condition{
+				Type:               ConditionWarnings,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: 3,
+				LastTransitionTime: test.wantTime,
+				Reason:             "AcceptedWithWarnings",
+				Message:            "spec.database.instances: 1 instance has no replica; spec.database.backup is disabled",
+			}
+			if len(conditions) != 1 || conditions[0] != want {
+				t.Errorf("WarningsConditions() = %+v, want [%+v]", conditions, want)
+			}
+		})
+	}
+}
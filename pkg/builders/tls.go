@@ -0,0 +1,65 @@
+package builders
+
+import (
+	"cmp"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultClusterIssuer is the defaultClusterIssuer of the platform cert-manager (see system/stolos-system.yml), used
+// when the StolosPlatform resource cannot be read.
+const DefaultClusterIssuer = "letsencrypt-staging"
+
+// certManagerIssuerAnnotation asks cert-manager to issue the certificates of an Ingress from a ClusterIssuer.
+const certManagerIssuerAnnotation = "cert-manager.io/cluster-issuer"
+
+// TLSSpec serves an Ingress over HTTPS with a certificate issued by cert-manager.
+type TLSSpec struct {
+	Enabled bool `json:"enabled"`
+	// ClusterIssuer is the cert-manager ClusterIssuer, e.g. "letsencrypt-prod". It defaults to the platform default.
+	ClusterIssuer string `json:"clusterIssuer,omitempty"`
+	// SecretName is the Secret cert-manager stores the certificate in. It defaults to "<ingress name>-tls".
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// IsEnabled reports whether cert-manager TLS is requested. It is safe to call on a nil spec.
+func (spec *TLSSpec) IsEnabled() bool {
+	return spec != nil && spec.Enabled
+}
+
+// Validate checks the issuer and secret names when TLS is enabled.
+func (spec *TLSSpec) Validate(path string) error {
+	if !spec.IsEnabled() {
+		return nil
+	}
+	if spec.ClusterIssuer != "" {
+		if errs := validation.IsDNS1123Subdomain(spec.ClusterIssuer); len(errs) > 0 {
+			return fmt.Errorf("%s.clusterIssuer %q must be a lowercase RFC 1123 subdomain", path, spec.ClusterIssuer)
+		}
+	}
+	if spec.SecretName != "" {
+		if errs := validation.IsDNS1123Subdomain(spec.SecretName); len(errs) > 0 {
+			return fmt.Errorf("%s.secretName %q must be a lowercase RFC 1123 subdomain", path, spec.SecretName)
+		}
+	}
+	return nil
+}
+
+// SetDefaults fills in the issuer when TLS is enabled: the spec.certManager.defaultClusterIssuer of the StolosPlatform
+// read through lookup, then DefaultClusterIssuer. The secret name depends on the Ingress and is derived by it.
+func (spec *TLSSpec) SetDefaults(lookup ObjectLookup) error {
+	if !spec.IsEnabled() || spec.ClusterIssuer != "" {
+		return nil
+	}
+	platform, err := lookupStolosPlatform(lookup)
+	if err != nil {
+		return err
+	}
+	if platform != nil {
+		spec.ClusterIssuer, _, _ = unstructured.NestedString(platform.Object, "spec", "certManager", "defaultClusterIssuer")
+	}
+	spec.ClusterIssuer = cmp.Or(spec.ClusterIssuer, DefaultClusterIssuer)
+	return nil
+}
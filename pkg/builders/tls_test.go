@@ -0,0 +1,34 @@
+package builders
+
+import (
+	"testing"
+)
+
+func TestTLSSpecSetDefaults(t *testing.T) {
+	platform := platformLookup(map[string]interface{}{
+		"certManager": map[string]interface{}{"defaultClusterIssuer": "letsencrypt-prod"},
+	}, nil)
+
+	for _, test := range []struct {
+		name   string
+		spec   *TLSSpec
+		lookup ObjectLookup
+		want   string
+	}{
+		{name: "disabled", spec: &TLSSpec{}, lookup: platform, want: ""},
+		{name: "explicit", spec: &TLSSpec{Enabled: true, ClusterIssuer: "internal-ca"}, lookup: platform, want: "internal-ca"},
+		{name: "platform", spec: &TLSSpec{Enabled: true}, lookup: platform, want: "letsencrypt-prod"},
+		{name: "platform without issuer", spec: &TLSSpec{Enabled: true}, lookup: platformLookup(map[string]interface{}{}, nil), want: DefaultClusterIssuer},
+		{name: "forbidden", spec: &TLSSpec{Enabled: true}, lookup: platformLookup(nil, errPlatformForbidden), want: DefaultClusterIssuer},
+		{name: "no lookup", spec: &TLSSpec{Enabled: true}, want: DefaultClusterIssuer},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.spec.SetDefaults(test.lookup); err != nil {
+				t.Fatalf("SetDefaults() error = %v", err)
+			}
+			if test.spec.ClusterIssuer != test.want {
+				t.Errorf("ClusterIssuer = %q, want %q", test.spec.ClusterIssuer, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package flighttest is a golden-file harness for flights.
+//
+// Every input custom resource is rendered through the flight and the emitted JSON is compared against a golden file
+// checked in under testdata/golden. Run the tests with -update to regenerate the golden files after an intended change:
+//
+//	go test ./pkg/v1 -update
+package flighttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the rendered output")
+
+// GoldenDir is where the golden files are stored, relative to the package under test.
+const GoldenDir = "testdata/golden"
+
+// RenderFunc renders the flight output for the custom resource read from r.
+type RenderFunc func(r io.Reader) ([]byte, error)
+
+// Run renders every file matched by patterns and compares the output against its golden file.
+// When no pattern is given, the YAML files in testdata are used.
+//
+// The golden file of "testdata/minimal.yaml" is "testdata/golden/minimal.json". Flights returning an error are
+// recorded as "error: <message>" so validation failures can be covered by the same harness.
+func Run(t *testing.T, render RenderFunc, patterns ...string) {
+	t.Helper()
+
+	if len(patterns) == 0 {
+		patterns = []string{"testdata/*.yaml"}
+	}
+
+	var inputs []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatalf("invalid pattern %q: %v", pattern, err)
+		}
+		inputs = append(inputs, matches...)
+	}
+	if len(inputs) == 0 {
+		t.Fatalf("no inputs matched %v", patterns)
+	}
+
+	for _, input := range inputs {
+		name := goldenName(input)
+		t.Run(name, func(t *testing.T) {
+			got, err := renderFile(input, render)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join(GoldenDir, name+".json")
+			if *update {
+				if err := os.MkdirAll(GoldenDir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("output of %s does not match %s (run with -update if the change is intended)\n%s", input, golden, diff(want, got))
+			}
+		})
+	}
+}
+
+// JSON adapts a render function returning resources, such as a flight's RenderFrom, into a RenderFunc.
+func JSON[T any](render func(r io.Reader) (T, error)) RenderFunc {
+	return func(r io.Reader) ([]byte, error) {
+		resources, err := render(r)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(resources)
+	}
+}
+
+// FromStdin adapts a flight run function reading its custom resource from os.Stdin into a RenderFunc.
+func FromStdin(run func() ([]byte, error)) RenderFunc {
+	return func(r io.Reader) ([]byte, error) {
+		stdin, err := os.CreateTemp("", "flighttest-stdin-*")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(stdin.Name())
+		defer stdin.Close()
+
+		if _, err := io.Copy(stdin, r); err != nil {
+			return nil, err
+		}
+		if _, err := stdin.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		original := os.Stdin
+		os.Stdin = stdin
+		defer func() { os.Stdin = original }()
+
+		return run()
+	}
+}
+
+// renderFile renders input and returns the normalized output stored in golden files.
+func renderFile(input string, render RenderFunc) ([]byte, error) {
+	file, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rendered, err := render(file)
+	if err != nil {
+		return []byte(fmt.Sprintf("error: %v\n", err)), nil
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, rendered, "", "  "); err != nil {
+		return nil, fmt.Errorf("flight output is not valid JSON: %w", err)
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// goldenName strips the directory and YAML extension: "../../Kind.yaml.example" becomes "Kind.example".
+func goldenName(input string) string {
+	name := filepath.Base(input)
+	name = strings.Replace(name, ".yaml", "", 1)
+	return strings.Replace(name, ".yml", "", 1)
+}
+
+// diff reports the first line where want and got diverge, which is enough to locate the change in the golden file.
+func diff(want, got []byte) string {
+	wantLines := strings.Split(string(want), "\n")
+	gotLines := strings.Split(string(got), "\n")
+
+	for i := 0; i < max(len(wantLines), len(gotLines)); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %s\n  got:  %s", i+1, w, g)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package flighttest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	render := func(r io.Reader) ([]byte, error) {
+		input, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(string(input), "fail") {
+			return nil, errors.New("spec.image is required")
+		}
+		return json.Marshal(map[string]string{"input": strings.TrimSpace(string(input))})
+	}
+
+	Run(t, render)
+}
+
+func TestGoldenName(t *testing.T) {
+	for input, want := range map[string]string{
+		"testdata/minimal.yaml":          "minimal",
+		"../../FullStack.yaml.example":   "FullStack.example",
+		"testdata/airway.yml":            "airway",
+		"testdata/with.dots.in.name.yml": "with.dots.in.name",
+	} {
+		if got := goldenName(input); got != want {
+			t.Errorf("goldenName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
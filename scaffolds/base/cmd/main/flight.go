@@ -3,14 +3,11 @@ package main
 import (
 	_ "embed"
 	"encoding/json"
-	"io"
 	"os"
 
 	stolos_yoke "github.com/stolos-cloud/stolos/yoke-base/pkg/stolos-yoke"
-	corev1 "k8s.io/api/core/v1"
+	v1 "github.com/stolos-cloud/test-template/scaffolds/base/pkg/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
-
-	"github.com/yokecd/yoke/pkg/flight"
 )
 
 //go:embed "AirwayInputs.yml"
@@ -32,41 +29,14 @@ func main() {
 	}
 	airway := manifest.Spec
 
-	stolos_yoke.Run[Base](airway, run)
+	stolos_yoke.Run[v1.Base](airway, run)
 }
 
 func run() ([]byte, error) {
-	var base Base // Yoke will pass your Custom Resource instance here via stdin.
-	if err := yaml.NewYAMLToJSONDecoder(os.Stdin).Decode(&base); err != nil && err != io.EOF {
-		return nil, err
-	}
-
-	// Validation step (Customize)
-	if err := validateSpec(&base); err != nil && err != io.EOF {
+	// Yoke will pass your Custom Resource instance here via stdin.
+	resources, err := v1.RenderFrom(os.Stdin)
+	if err != nil {
 		return nil, err
 	}
-
-	// Create the k8s resources for your application.
-	return json.Marshal([]flight.Resource{
-		createResources(base),
-	})
-}
-
-func validateSpec(base *Base) error {
-	// TODO : Validate the spec and set sane defaults
-
-	if base != nil {
-		return nil
-	}
-
-	return nil
-}
-
-// TODO : Implement functions which return standard k8s resources to create.
-func createResources(base Base) *corev1.ConfigMap {
-	return &corev1.ConfigMap{
-		Data: map[string]string{
-			"HelloWorld": base.Spec.SomeProperty,
-		},
-	}
+	return json.Marshal(resources)
 }
@@ -1,4 +1,4 @@
-package main
+package v1
 
 import (
 	"encoding/json"
@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	APIVersion = "templates.stolos.cloud/v1"
+	APIVersion = "stolos.cloud/v1alpha1"
 	KindBase   = "Base"
 )
 
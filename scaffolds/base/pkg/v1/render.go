@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+// RenderFrom decodes a Base from r and renders it.
+// We use the yaml to json decoder so that yaml definitions can be passed manually when testing for convenience.
+func RenderFrom(r io.Reader) ([]flight.Resource, error) {
+	var base Base
+	if err := yaml.NewYAMLToJSONDecoder(r).Decode(&base); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return Render(base)
+}
+
+// Render validates the Base, applies defaults, and returns the resources to deploy.
+// It has no side effects so it can be called in-process by other Go programs.
+func Render(base Base) ([]flight.Resource, error) {
+	// Validation step (Customize)
+	if err := validateSpec(&base); err != nil {
+		return nil, err
+	}
+
+	// Create the k8s resources for your application.
+	return []flight.Resource{
+		createResources(base),
+	}, nil
+}
+
+func validateSpec(base *Base) error {
+	// TODO : Validate the spec and set sane defaults
+
+	if base != nil {
+		return nil
+	}
+
+	return nil
+}
+
+// TODO : Implement functions which return standard k8s resources to create.
+func createResources(base Base) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		Data: map[string]string{
+			"HelloWorld": base.Spec.SomeProperty,
+		},
+	}
+}
@@ -0,0 +1,11 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stolos-cloud/test-template/pkg/flighttest"
+)
+
+func TestRender(t *testing.T) {
+	flighttest.Run(t, flighttest.JSON(RenderFrom), "../../*.yaml.example", "testdata/*.yaml")
+}
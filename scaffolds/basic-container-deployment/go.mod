@@ -4,6 +4,7 @@ go 1.25.0
 
 require (
 	github.com/stolos-cloud/stolos/yoke-base v0.0.0-20251108204810-a3f86994075e
+	github.com/stolos-cloud/test-template/pkg v0.0.0
 	github.com/yokecd/yoke v0.17.3
 	k8s.io/api v0.34.1
 	k8s.io/apimachinery v0.34.1
@@ -14,7 +15,6 @@ require (
 	github.com/go-logr/logr v1.4.3 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
-	github.com/kr/text v0.2.0 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.3-0.20250322232337-35a7c28c31ee // indirect
 	github.com/x448/float16 v0.8.4 // indirect
@@ -31,3 +31,4 @@ require (
 	sigs.k8s.io/yaml v1.6.0 // indirect
 )
 
+replace github.com/stolos-cloud/test-template/pkg => ../../pkg
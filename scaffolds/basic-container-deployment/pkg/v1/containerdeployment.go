@@ -1,14 +1,15 @@
-package main
+package v1
 
 import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/stolos-cloud/test-template/pkg/builders"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
-	APIVersion              = "templates.stolos.cloud/v1"
+	APIVersion              = "stolos.cloud/v1alpha1"
 	KindContainerDeployment = "ContainerDeployment"
 )
 
@@ -23,9 +24,14 @@ type ContainerDeployment struct {
 
 // ContainerDeploymentSpec defines the desired container workload.
 type ContainerDeploymentSpec struct {
-	Image    string `json:"image"`
-	Replicas int32  `json:"replicas,omitempty" Default:"1"`
-	Port     int32  `json:"port,omitempty" Default:"80"`
+	Image     string                  `json:"image"`
+	Replicas  int32                   `json:"replicas,omitempty" Default:"1"`
+	Port      int32                   `json:"port,omitempty" Default:"80"`
+	Probes    *builders.ProbesSpec    `json:"probes,omitempty"`
+	Resources *builders.ResourcesSpec `json:"resources,omitempty"`
+	// Env is appended after the variables injected by the template, so values may reference them with $(NAME).
+	Env     []builders.EnvVar        `json:"env,omitempty"`
+	EnvFrom []builders.EnvFromSource `json:"envFrom,omitempty"`
 }
 
 // MarshalJSON sets apiVersion and kind so users do not need to explicitly fill them out.
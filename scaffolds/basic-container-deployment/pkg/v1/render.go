@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/stolos-cloud/test-template/pkg/builders"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+// RenderFrom decodes a ContainerDeployment from r and renders it.
+// We use the yaml to json decoder so that yaml definitions can be passed manually when testing for convenience.
+func RenderFrom(r io.Reader) ([]flight.Resource, error) {
+	var deployment ContainerDeployment
+	if err := yaml.NewYAMLToJSONDecoder(r).Decode(&deployment); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return Render(deployment)
+}
+
+// Render validates the ContainerDeployment, applies defaults, and returns the resources to deploy.
+// It has no side effects so it can be called in-process by other Go programs.
+func Render(deployment ContainerDeployment) ([]flight.Resource, error) {
+	// Validation and defaulting
+	if err := validateSpec(&deployment); err != nil {
+		return nil, err
+	}
+
+	// Create the k8s resources for your application.
+	return []flight.Resource{
+		createDeployment(deployment),
+	}, nil
+}
+
+func validateSpec(deployment *ContainerDeployment) error {
+	if deployment.Spec.Image == "" {
+		return fmt.Errorf("spec.image is required")
+	}
+	if deployment.Spec.Replicas < 0 {
+		return fmt.Errorf("spec.replicas cannot be negative")
+	}
+	if err := deployment.Spec.Probes.Validate("spec.probes"); err != nil {
+		return err
+	}
+	if err := deployment.Spec.Resources.Validate("spec.resources"); err != nil {
+		return err
+	}
+	if err := builders.ValidateEnv("spec.env", deployment.Spec.Env, nil); err != nil {
+		return err
+	}
+	if err := builders.ValidateEnvFrom("spec.envFrom", deployment.Spec.EnvFrom); err != nil {
+		return err
+	}
+
+	// Defaulting
+	if deployment.Spec.Replicas == 0 {
+		deployment.Spec.Replicas = 1
+	}
+	if deployment.Spec.Port == 0 {
+		deployment.Spec.Port = 80
+	}
+
+	return nil
+}
+
+func createDeployment(resource ContainerDeployment) *appsv1.Deployment {
+	return builders.Deployment{
+		Name:      resource.Name,
+		Namespace: resource.Namespace,
+		Replicas:  resource.Spec.Replicas,
+		Image:     resource.Spec.Image,
+		Port:      resource.Spec.Port,
+		Probes:    resource.Spec.Probes,
+		Resources: resource.Spec.Resources,
+		Env:       builders.Env(nil, resource.Spec.Env),
+		EnvFrom:   builders.EnvFrom(resource.Spec.EnvFrom),
+	}.Build()
+}
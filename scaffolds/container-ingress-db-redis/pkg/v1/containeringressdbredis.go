@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/stolos-cloud/test-template/pkg/builders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+const (
+	ContainerIngressDBRedisAPIVersion = "stolos.cloud/v1alpha1"
+	KindContainerIngressDBRedis       = "ContainerIngressDBRedis"
+)
+
+// ContainerIngressDBRedis wires together a backend deployment, ingress, PostgreSQL, and cache layer.
+type ContainerIngressDBRedis struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ContainerIngressDBRedisSpec   `json:"spec"`
+	Status            ContainerIngressDBRedisStatus `json:"status,omitzero"`
+}
+
+// ContainerIngressDBRedisStatus is published by the flight from the deployed resources. The ATC maintains the Ready condition.
+type ContainerIngressDBRedisStatus struct {
+	Conditions    flight.Conditions        `json:"conditions,omitempty"`
+	URL           string                   `json:"url,omitempty"`
+	DatabaseHost  string                   `json:"databaseHost,omitempty"`
+	CacheEndpoint string                   `json:"cacheEndpoint,omitempty"`
+	App           builders.ComponentStatus `json:"app,omitzero"`
+	Database      builders.ComponentStatus `json:"database,omitzero"`
+	Cache         builders.ComponentStatus `json:"cache,omitzero"`
+}
+
+// ContainerIngressDBRedisSpec defines backend, ingress, database, and cache knobs.
+type ContainerIngressDBRedisSpec struct {
+	Image         string                    `json:"image"`
+	Replicas      int32                     `json:"replicas,omitempty" Default:"2"`
+	Autoscaling   *builders.AutoscalingSpec `json:"autoscaling,omitempty"`
+	ContainerPort int32                     `json:"containerPort,omitempty" Default:"8080"`
+	Probes        *builders.ProbesSpec      `json:"probes,omitempty"`
+	Resources     *builders.ResourcesSpec   `json:"resources,omitempty"`
+	// Env is appended after the variables injected by the template, so values may reference them with $(NAME).
+	Env           []builders.EnvVar        `json:"env,omitempty"`
+	EnvFrom       []builders.EnvFromSource `json:"envFrom,omitempty"`
+	Host          string                   `json:"host,omitempty"`
+	Path          string                   `json:"path,omitempty" Default:"/"`
+	TLSSecretName string                   `json:"tlsSecretName,omitempty"`
+	// TLS has cert-manager issue the certificate, stored in tlsSecretName when set.
+	TLS *builders.TLSSpec `json:"tls,omitempty"`
+	// Ingress selects the provider: contour for an HTTPProxy with timeouts, retries and weighted services, or gateway
+	// for a Gateway API HTTPRoute.
+	Ingress *builders.IngressSpec `json:"ingress,omitempty"`
+	// Aliases are more hosts serving the routes of host, e.g. the apex domain next to "www.".
+	Aliases []string `json:"aliases,omitempty"`
+	// Routes replace the host and path rule, e.g. to serve versioned API prefixes, other domains or other container
+	// ports. TLS covers every host.
+	Routes   []builders.IngressRouteSpec `json:"routes,omitempty"`
+	Database DatabaseSpec                `json:"database"`
+	Cache    CacheSpec                   `json:"cache"`
+}
+
+// DatabaseSpec matches the CNPG inputs reused across scaffolds.
+type DatabaseSpec = builders.DatabaseSpec
+
+// CacheSpec configures Redis / Valkey deployment options.
+type CacheSpec = builders.CacheSpec
+
+func (c ContainerIngressDBRedis) MarshalJSON() ([]byte, error) {
+	c.APIVersion = ContainerIngressDBRedisAPIVersion
+	c.Kind = KindContainerIngressDBRedis
+	type Alias ContainerIngressDBRedis
+	return json.Marshal(Alias(c))
+}
+
+func (c *ContainerIngressDBRedis) UnmarshalJSON(data []byte) error {
+	type Alias ContainerIngressDBRedis
+	if err := json.Unmarshal(data, (*Alias)(c)); err != nil {
+		return err
+	}
+	if c.APIVersion != "" && c.APIVersion != ContainerIngressDBRedisAPIVersion {
+		return fmt.Errorf("unexpected api version: expected %s but got %s", ContainerIngressDBRedisAPIVersion, c.APIVersion)
+	}
+	if c.Kind != "" && c.Kind != KindContainerIngressDBRedis {
+		return fmt.Errorf("unexpected kind: expected %s but got %s", KindContainerIngressDBRedis, c.Kind)
+	}
+	return nil
+}
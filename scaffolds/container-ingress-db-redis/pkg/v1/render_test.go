@@ -0,0 +1,27 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stolos-cloud/test-template/pkg/flighttest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// goldenNow keeps the transition time of the status conditions out of the golden files.
+func goldenNow() metav1.Time {
+	return metav1.NewTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestRender(t *testing.T) {
+	// Pretend every generated Secret already exists so the golden files do not depend on random passwords.
+	renderer := Renderer{
+		LookupSecret: func(namespace, name string) (*corev1.Secret, error) {
+			return &corev1.Secret{Data: map[string][]byte{"password": []byte("golden-password")}}, nil
+		},
+		Now: goldenNow,
+	}
+
+	flighttest.Run(t, flighttest.JSON(renderer.RenderFrom), "../../*.yaml.example", "testdata/*.yaml")
+}
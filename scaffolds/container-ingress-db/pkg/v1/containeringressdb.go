@@ -0,0 +1,88 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/stolos-cloud/test-template/pkg/builders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+const (
+	ContainerIngressDBAPIVersion = "stolos.cloud/v1alpha1"
+	KindContainerIngressDB       = "ContainerIngressDB"
+)
+
+// ContainerIngressDB models a workload exposed via ingress with a managed PostgreSQL cluster.
+type ContainerIngressDB struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ContainerIngressDBSpec   `json:"spec"`
+	Status            ContainerIngressDBStatus `json:"status,omitzero"`
+}
+
+// ContainerIngressDBStatus is published by the flight from the deployed resources. The ATC maintains the Ready condition.
+type ContainerIngressDBStatus struct {
+	Conditions   flight.Conditions        `json:"conditions,omitempty"`
+	URL          string                   `json:"url,omitempty"`
+	DatabaseHost string                   `json:"databaseHost,omitempty"`
+	App          builders.ComponentStatus `json:"app,omitzero"`
+	Database     builders.ComponentStatus `json:"database,omitzero"`
+	// Migrations is the state of the migrations Job of the current spec, when migrations are configured.
+	Migrations *builders.MigrationsStatus `json:"migrations,omitempty"`
+}
+
+// ContainerIngressDBSpec configures the backend workload, ingress, and database cluster.
+type ContainerIngressDBSpec struct {
+	Image         string                    `json:"image"`
+	Replicas      int32                     `json:"replicas,omitempty" Default:"2"`
+	Autoscaling   *builders.AutoscalingSpec `json:"autoscaling,omitempty"`
+	ContainerPort int32                     `json:"containerPort,omitempty" Default:"8080"`
+	Probes        *builders.ProbesSpec      `json:"probes,omitempty"`
+	Resources     *builders.ResourcesSpec   `json:"resources,omitempty"`
+	// Env is appended after the variables injected by the template, so values may reference them with $(NAME).
+	Env           []builders.EnvVar        `json:"env,omitempty"`
+	EnvFrom       []builders.EnvFromSource `json:"envFrom,omitempty"`
+	Host          string                   `json:"host,omitempty"`
+	Path          string                   `json:"path,omitempty" Default:"/"`
+	TLSSecretName string                   `json:"tlsSecretName,omitempty"`
+	// TLS has cert-manager issue the certificate, stored in tlsSecretName when set.
+	TLS *builders.TLSSpec `json:"tls,omitempty"`
+	// Ingress selects the provider: contour for an HTTPProxy with timeouts, retries and weighted services, or gateway
+	// for a Gateway API HTTPRoute.
+	Ingress *builders.IngressSpec `json:"ingress,omitempty"`
+	// Aliases are more hosts serving the routes of host, e.g. the apex domain next to "www.".
+	Aliases []string `json:"aliases,omitempty"`
+	// Routes replace the host and path rule, e.g. to serve versioned API prefixes, other domains or other container
+	// ports. TLS covers every host.
+	Routes   []builders.IngressRouteSpec `json:"routes,omitempty"`
+	Database DatabaseSpec                `json:"database"`
+	// Migrations run in a Job the application pods wait for, with the same environment as the application.
+	Migrations *builders.MigrationsSpec `json:"migrations,omitempty"`
+}
+
+// DatabaseSpec holds CNPG configuration options shared with the other database-backed scaffolds.
+type DatabaseSpec = builders.DatabaseSpec
+
+func (c ContainerIngressDB) MarshalJSON() ([]byte, error) {
+	c.APIVersion = ContainerIngressDBAPIVersion
+	c.Kind = KindContainerIngressDB
+	type Alias ContainerIngressDB
+	return json.Marshal(Alias(c))
+}
+
+func (c *ContainerIngressDB) UnmarshalJSON(data []byte) error {
+	type Alias ContainerIngressDB
+	if err := json.Unmarshal(data, (*Alias)(c)); err != nil {
+		return err
+	}
+	if c.APIVersion != "" && c.APIVersion != ContainerIngressDBAPIVersion {
+		return fmt.Errorf("unexpected api version: expected %s but got %s", ContainerIngressDBAPIVersion, c.APIVersion)
+	}
+	if c.Kind != "" && c.Kind != KindContainerIngressDB {
+		return fmt.Errorf("unexpected kind: expected %s but got %s", KindContainerIngressDB, c.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/stolos-cloud/test-template/pkg/builders"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+// RenderFrom decodes a ContainerIngressDB from r and renders it without reading the cluster, see Renderer.
+// We use the yaml to json decoder so that yaml definitions can be passed manually when testing for convenience.
+func RenderFrom(r io.Reader) ([]flight.Resource, error) {
+	return Renderer{}.RenderFrom(r)
+}
+
+// Render renders the ContainerIngressDB without reading the cluster, see Renderer.
+func Render(resource ContainerIngressDB) ([]flight.Resource, error) {
+	return Renderer{}.Render(resource)
+}
+
+// Renderer renders ContainerIngressDBs with the cluster reads of the flight. The zero value reads nothing: it reports components that do not exist yet and derives the hosts from the default base domain.
+type Renderer struct {
+	// LookupObject reads the deployed workloads back to report their readiness in the ContainerIngressDB status, and
+	// the StolosPlatform holding the base domain.
+	LookupObject builders.ObjectLookup
+	// Hosts derives the hostnames left empty in the ContainerIngressDB. The flight sets it from AirwayInputs.yml.
+	Hosts builders.HostDefaults
+	// Now timestamps the status conditions. It defaults to metav1.Now.
+	Now func() metav1.Time
+}
+
+// RenderFrom decodes a ContainerIngressDB from in and renders it.
+func (r Renderer) RenderFrom(in io.Reader) ([]flight.Resource, error) {
+	var resource ContainerIngressDB
+	if err := yaml.NewYAMLToJSONDecoder(in).Decode(&resource); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return r.Render(resource)
+}
+
+// Render validates the ContainerIngressDB, applies defaults, and returns the resources to deploy followed by the
+// ContainerIngressDB itself carrying its status.
+// Apart from the LookupObject reads it has no side effects so it can be called in-process by other Go programs.
+func (r Renderer) Render(resource ContainerIngressDB) ([]flight.Resource, error) {
+	if err := r.validateSpec(&resource); err != nil {
+		return nil, err
+	}
+	// Warnings do not fail the render.
+	warnings := resource.Spec.Database.Warnings("spec.database")
+	status, err := r.createStatus(resource, warnings)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []flight.Resource{
+		createDeployment(resource),
+		createService(resource),
+		createCNPGCluster(resource),
+	}
+	if ingress := createIngress(resource); ingress != nil {
+		resources = append(resources, ingress)
+	}
+	if hpa := createHorizontalPodAutoscaler(resource); hpa != nil {
+		resources = append(resources, hpa)
+	}
+	for _, object := range slices.Concat(createHTTPProxies(resource), createHTTPRoutes(resource)) {
+		resources = append(resources, object)
+	}
+	for _, object := range []*unstructured.Unstructured{
+		createCertificate(resource),
+		createObjectStore(resource),
+		createScheduledBackup(resource),
+		createRecoveryObjectStore(resource),
+		createExtensions(resource),
+	} {
+		if object != nil {
+			resources = append(resources, object)
+		}
+	}
+	for _, pooler := range createPoolers(resource) {
+		resources = append(resources, pooler)
+	}
+	resources = append(resources, createMigrations(resource)...)
+
+	resources = append(resources, status)
+	return resources, nil
+}
+
+func (r Renderer) validateSpec(resource *ContainerIngressDB) error {
+	if resource.Spec.Image == "" {
+		return fmt.Errorf("spec.image is required")
+	}
+	if resource.Spec.Host == "" {
+		host, err := r.Hosts.Host(r.LookupObject, resource.Name, resource.Namespace)
+		if err != nil {
+			return fmt.Errorf("spec.host: %w", err)
+		}
+		resource.Spec.Host = host
+	}
+	if err := resource.Spec.Autoscaling.Validate("spec.autoscaling"); err != nil {
+		return err
+	}
+	if err := resource.Spec.TLS.Validate("spec.tls"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Ingress.Validate("spec.ingress"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Ingress.ValidateTLS("spec", resource.Spec.TLS, resource.Spec.TLSSecretName); err != nil {
+		return err
+	}
+	if err := resource.Spec.Probes.Validate("spec.probes"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Resources.Validate("spec.resources"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Database.Validate("spec.database"); err != nil {
+		return err
+	}
+	if err := builders.ValidateEnv("spec.env", resource.Spec.Env, injectedEnv(*resource)); err != nil {
+		return err
+	}
+	if err := builders.ValidateEnvFrom("spec.envFrom", resource.Spec.EnvFrom); err != nil {
+		return err
+	}
+	if err := resource.Spec.Migrations.Validate("spec.migrations"); err != nil {
+		return err
+	}
+	if resource.Spec.Replicas <= 0 {
+		resource.Spec.Replicas = 2
+	}
+	if resource.Spec.ContainerPort == 0 {
+		resource.Spec.ContainerPort = 8080
+	}
+	if resource.Spec.Path == "" {
+		resource.Spec.Path = "/"
+	}
+	if err := resource.Spec.Ingress.ValidateRoutes("spec", resource.Spec.Routes, resource.Spec.Aliases, resource.Spec.ContainerPort); err != nil {
+		return err
+	}
+	resource.Spec.Autoscaling.SetDefaults()
+	if err := resource.Spec.TLS.SetDefaults(r.LookupObject); err != nil {
+		return fmt.Errorf("spec.tls: %w", err)
+	}
+	resource.Spec.Ingress.SetDefaults()
+	resource.Spec.Database.SetDefaults()
+	return nil
+}
+
+func createDeployment(resource ContainerIngressDB) *appsv1.Deployment {
+	return appDeployment(resource).Build()
+}
+
+func createHorizontalPodAutoscaler(resource ContainerIngressDB) *autoscalingv2.HorizontalPodAutoscaler {
+	return appDeployment(resource).HorizontalPodAutoscaler()
+}
+
+func appDeployment(resource ContainerIngressDB) builders.Deployment {
+	deployment := builders.Deployment{
+		Name:        resource.Name,
+		Namespace:   resource.Namespace,
+		Replicas:    resource.Spec.Replicas,
+		Autoscaling: resource.Spec.Autoscaling,
+		Image:       resource.Spec.Image,
+		Port:        resource.Spec.ContainerPort,
+		Probes:      resource.Spec.Probes,
+		Resources:   resource.Spec.Resources,
+		Env:         builders.Env(injectedEnv(resource), resource.Spec.Env),
+		EnvFrom:     builders.EnvFrom(resource.Spec.EnvFrom),
+	}
+	if resource.Spec.Migrations.IsEnabled() {
+		migrations := appMigrations(resource)
+		deployment.InitContainers = []corev1.Container{migrations.WaitContainer()}
+		deployment.ServiceAccountName = migrations.ServiceAccountName()
+	}
+	return deployment
+}
+
+// createMigrations returns the migrations Job and the RBAC letting the application wait for it, or nil when
+// migrations are not configured.
+func createMigrations(resource ContainerIngressDB) []flight.Resource {
+	if !resource.Spec.Migrations.IsEnabled() {
+		return nil
+	}
+	migrations := appMigrations(resource)
+	return []flight.Resource{
+		migrations.Job(),
+		migrations.ServiceAccount(),
+		migrations.Role(),
+		migrations.RoleBinding(),
+	}
+}
+
+func appMigrations(resource ContainerIngressDB) builders.Migrations {
+	return builders.Migrations{
+		Name:      resource.Name,
+		Namespace: resource.Namespace,
+		Spec:      resource.Spec.Migrations,
+		Env:       builders.Env(injectedEnv(resource), resource.Spec.Env),
+		EnvFrom:   builders.EnvFrom(resource.Spec.EnvFrom),
+	}
+}
+
+// injectedEnv returns the variables the template sets on the application container.
+func injectedEnv(resource ContainerIngressDB) []corev1.EnvVar {
+	return database(resource).Env()
+}
+
+func createService(resource ContainerIngressDB) *corev1.Service {
+	return builders.Service{
+		Name:       resource.Name,
+		Namespace:  resource.Namespace,
+		PortName:   "http",
+		Port:       80,
+		TargetPort: intstr.FromInt32(resource.Spec.ContainerPort),
+		// The routes to other container ports go through Service ports of the same number.
+		AdditionalPorts: appIngress(resource).ServicePorts(),
+	}.Build()
+}
+
+func createIngress(resource ContainerIngressDB) *networkingv1.Ingress {
+	return appIngress(resource).Build()
+}
+
+func createHTTPProxies(resource ContainerIngressDB) []*unstructured.Unstructured {
+	return appIngress(resource).HTTPProxies()
+}
+
+func createCertificate(resource ContainerIngressDB) *unstructured.Unstructured {
+	return appIngress(resource).Certificate()
+}
+
+func createHTTPRoutes(resource ContainerIngressDB) []*unstructured.Unstructured {
+	return appIngress(resource).HTTPRoutes()
+}
+
+func appIngress(resource ContainerIngressDB) builders.Ingress {
+	return builders.Ingress{
+		Name:          resource.Name,
+		Namespace:     resource.Namespace,
+		Host:          resource.Spec.Host,
+		Path:          resource.Spec.Path,
+		Aliases:       resource.Spec.Aliases,
+		Routes:        resource.Spec.Routes,
+		ServiceName:   resource.Name,
+		ContainerPort: resource.Spec.ContainerPort,
+		TLSSecretName: resource.Spec.TLSSecretName,
+		TLS:           resource.Spec.TLS,
+		Spec:          resource.Spec.Ingress,
+	}
+}
+
+func createCNPGCluster(resource ContainerIngressDB) *unstructured.Unstructured {
+	return database(resource).Cluster()
+}
+
+func createObjectStore(resource ContainerIngressDB) *unstructured.Unstructured {
+	return database(resource).ObjectStore()
+}
+
+func createScheduledBackup(resource ContainerIngressDB) *unstructured.Unstructured {
+	return database(resource).ScheduledBackup()
+}
+
+func createRecoveryObjectStore(resource ContainerIngressDB) *unstructured.Unstructured {
+	return database(resource).RecoveryObjectStore()
+}
+
+func createExtensions(resource ContainerIngressDB) *unstructured.Unstructured {
+	return database(resource).Extensions()
+}
+
+func createPoolers(resource ContainerIngressDB) []*unstructured.Unstructured {
+	return database(resource).Poolers()
+}
+
+func database(resource ContainerIngressDB) builders.Database {
+	return builders.Database{Namespace: resource.Namespace, Spec: resource.Spec.Database}
+}
+
+// createStatus returns the ContainerIngressDB with its status, written back by the ATC like for every scaffold.
+func (r Renderer) createStatus(resource ContainerIngressDB, warnings []string) (*ContainerIngressDB, error) {
+	status := ContainerIngressDBStatus{
+		URL:          appIngress(resource).URL(),
+		DatabaseHost: database(resource).Host(),
+	}
+
+	var err error
+	if status.App, err = appDeployment(resource).Status(r.LookupObject); err != nil {
+		return nil, err
+	}
+	if status.Database, err = database(resource).Status(r.LookupObject); err != nil {
+		return nil, err
+	}
+	if resource.Spec.Migrations.IsEnabled() {
+		migrations, err := appMigrations(resource).Status(r.LookupObject)
+		if err != nil {
+			return nil, err
+		}
+		status.Migrations = &migrations
+	}
+	status.Conditions = builders.WarningsConditions(resource.Status.Conditions, resource.Generation, r.now(), warnings)
+
+	resource.Status = status
+	return &resource, nil
+}
+
+func (r Renderer) now() metav1.Time {
+	if r.Now == nil {
+		return metav1.Now()
+	}
+	return r.Now()
+}
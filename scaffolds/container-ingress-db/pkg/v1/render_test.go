@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stolos-cloud/test-template/pkg/flighttest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// goldenNow keeps the transition time of the status conditions out of the golden files.
+func goldenNow() metav1.Time {
+	return metav1.NewTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestRender(t *testing.T) {
+	renderer := Renderer{Now: goldenNow}
+
+	flighttest.Run(t, flighttest.JSON(renderer.RenderFrom), "../../*.yaml.example", "testdata/*.yaml")
+}
+
+func TestRenderStatus(t *testing.T) {
+	// The migrations Job of the release completed, the application is not created yet.
+	renderer := Renderer{
+		LookupObject: func(apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
+			if kind != "Job" {
+				return nil, nil
+			}
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": name, "namespace": namespace},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "SuccessCriteriaMet", "status": "True"},
+						map[string]interface{}{"type": "Complete", "status": "True"},
+					},
+				},
+			}}, nil
+		},
+		Now: goldenNow,
+	}
+
+	flighttest.Run(t, flighttest.JSON(renderer.RenderFrom), "testdata/status/*.yaml")
+}
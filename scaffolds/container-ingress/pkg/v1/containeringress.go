@@ -0,0 +1,78 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/stolos-cloud/test-template/pkg/builders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+const (
+	ContainerIngressAPIVersion = "stolos.cloud/v1alpha1"
+	KindContainerIngress       = "ContainerIngress"
+)
+
+// ContainerIngress defines a container workload exposed via an Ingress.
+type ContainerIngress struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ContainerIngressSpec   `json:"spec"`
+	Status            ContainerIngressStatus `json:"status,omitzero"`
+}
+
+// ContainerIngressStatus is published by the flight from the deployed resources. The ATC maintains the Ready condition.
+type ContainerIngressStatus struct {
+	Conditions flight.Conditions        `json:"conditions,omitempty"`
+	URL        string                   `json:"url,omitempty"`
+	App        builders.ComponentStatus `json:"app,omitzero"`
+}
+
+// ContainerIngressSpec configures the Deployment, Service, and Ingress resources.
+type ContainerIngressSpec struct {
+	Image         string                    `json:"image"`
+	Replicas      int32                     `json:"replicas,omitempty" Default:"1"`
+	Autoscaling   *builders.AutoscalingSpec `json:"autoscaling,omitempty"`
+	ContainerPort int32                     `json:"containerPort,omitempty" Default:"8080"`
+	Probes        *builders.ProbesSpec      `json:"probes,omitempty"`
+	Resources     *builders.ResourcesSpec   `json:"resources,omitempty"`
+	// Env is appended after the variables injected by the template, so values may reference them with $(NAME).
+	Env           []builders.EnvVar        `json:"env,omitempty"`
+	EnvFrom       []builders.EnvFromSource `json:"envFrom,omitempty"`
+	Host          string                   `json:"host,omitempty"`
+	Path          string                   `json:"path,omitempty" Default:"\"/\""`
+	TLSSecretName string                   `json:"tlsSecretName,omitempty"`
+	// TLS has cert-manager issue the certificate, stored in tlsSecretName when set.
+	TLS *builders.TLSSpec `json:"tls,omitempty"`
+	// Ingress selects the provider: contour for an HTTPProxy with timeouts, retries and weighted services, or gateway
+	// for a Gateway API HTTPRoute.
+	Ingress *builders.IngressSpec `json:"ingress,omitempty"`
+	// Aliases are more hosts serving the routes of host, e.g. the apex domain next to "www.".
+	Aliases []string `json:"aliases,omitempty"`
+	// Routes replace the host and path rule, e.g. to serve versioned API prefixes, other domains or other container
+	// ports. TLS covers every host.
+	Routes []builders.IngressRouteSpec `json:"routes,omitempty"`
+}
+
+func (c ContainerIngress) MarshalJSON() ([]byte, error) {
+	c.APIVersion = ContainerIngressAPIVersion
+	c.Kind = KindContainerIngress
+	type Alias ContainerIngress
+	return json.Marshal(Alias(c))
+}
+
+func (c *ContainerIngress) UnmarshalJSON(data []byte) error {
+	type Alias ContainerIngress
+	if err := json.Unmarshal(data, (*Alias)(c)); err != nil {
+		return err
+	}
+	if c.APIVersion != "" && c.APIVersion != ContainerIngressAPIVersion {
+		return fmt.Errorf("unexpected api version: expected %s but got %s", ContainerIngressAPIVersion, c.APIVersion)
+	}
+	if c.Kind != "" && c.Kind != KindContainerIngress {
+		return fmt.Errorf("unexpected kind: expected %s but got %s", KindContainerIngress, c.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/stolos-cloud/test-template/pkg/builders"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+// RenderFrom decodes a ContainerIngress from r and renders it without reading the cluster, see Renderer.
+// We use the yaml to json decoder so that yaml definitions can be passed manually when testing for convenience.
+func RenderFrom(r io.Reader) ([]flight.Resource, error) {
+	return Renderer{}.RenderFrom(r)
+}
+
+// Render renders the ContainerIngress without reading the cluster, see Renderer.
+func Render(resource ContainerIngress) ([]flight.Resource, error) {
+	return Renderer{}.Render(resource)
+}
+
+// Renderer renders ContainerIngresss with the cluster reads of the flight. The zero value reads nothing: it reports components that do not exist yet and derives the hosts from the default base domain.
+type Renderer struct {
+	// LookupObject reads the deployed workloads back to report their readiness in the ContainerIngress status, and the
+	// StolosPlatform holding the base domain.
+	LookupObject builders.ObjectLookup
+	// Hosts derives the hostnames left empty in the ContainerIngress. The flight sets it from AirwayInputs.yml.
+	Hosts builders.HostDefaults
+}
+
+// RenderFrom decodes a ContainerIngress from in and renders it.
+func (r Renderer) RenderFrom(in io.Reader) ([]flight.Resource, error) {
+	var resource ContainerIngress
+	if err := yaml.NewYAMLToJSONDecoder(in).Decode(&resource); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return r.Render(resource)
+}
+
+// Render validates the ContainerIngress, applies defaults, and returns the resources to deploy followed by the
+// ContainerIngress itself carrying its status.
+// Apart from the LookupObject reads it has no side effects so it can be called in-process by other Go programs.
+func (r Renderer) Render(resource ContainerIngress) ([]flight.Resource, error) {
+	if err := r.validateSpec(&resource); err != nil {
+		return nil, err
+	}
+	status, err := r.createStatus(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []flight.Resource{
+		createDeployment(resource),
+		createService(resource),
+	}
+	if ingress := createIngress(resource); ingress != nil {
+		resources = append(resources, ingress)
+	}
+	if hpa := createHorizontalPodAutoscaler(resource); hpa != nil {
+		resources = append(resources, hpa)
+	}
+	for _, object := range slices.Concat(createHTTPProxies(resource), createHTTPRoutes(resource)) {
+		resources = append(resources, object)
+	}
+	if certificate := createCertificate(resource); certificate != nil {
+		resources = append(resources, certificate)
+	}
+
+	resources = append(resources, status)
+	return resources, nil
+}
+
+func (r Renderer) validateSpec(resource *ContainerIngress) error {
+	if resource.Spec.Image == "" {
+		return fmt.Errorf("spec.image is required")
+	}
+	if resource.Spec.Host == "" {
+		host, err := r.Hosts.Host(r.LookupObject, resource.Name, resource.Namespace)
+		if err != nil {
+			return fmt.Errorf("spec.host: %w", err)
+		}
+		resource.Spec.Host = host
+	}
+	if err := resource.Spec.Autoscaling.Validate("spec.autoscaling"); err != nil {
+		return err
+	}
+	if err := resource.Spec.TLS.Validate("spec.tls"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Ingress.Validate("spec.ingress"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Ingress.ValidateTLS("spec", resource.Spec.TLS, resource.Spec.TLSSecretName); err != nil {
+		return err
+	}
+	if err := resource.Spec.Probes.Validate("spec.probes"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Resources.Validate("spec.resources"); err != nil {
+		return err
+	}
+	if err := builders.ValidateEnv("spec.env", resource.Spec.Env, nil); err != nil {
+		return err
+	}
+	if err := builders.ValidateEnvFrom("spec.envFrom", resource.Spec.EnvFrom); err != nil {
+		return err
+	}
+	if resource.Spec.Replicas == 0 {
+		resource.Spec.Replicas = 1
+	}
+	if resource.Spec.ContainerPort == 0 {
+		resource.Spec.ContainerPort = 8080
+	}
+	if resource.Spec.Path == "" {
+		resource.Spec.Path = "/"
+	}
+	if err := resource.Spec.Ingress.ValidateRoutes("spec", resource.Spec.Routes, resource.Spec.Aliases, resource.Spec.ContainerPort); err != nil {
+		return err
+	}
+	resource.Spec.Autoscaling.SetDefaults()
+	if err := resource.Spec.TLS.SetDefaults(r.LookupObject); err != nil {
+		return fmt.Errorf("spec.tls: %w", err)
+	}
+	resource.Spec.Ingress.SetDefaults()
+	return nil
+}
+
+func createDeployment(resource ContainerIngress) *appsv1.Deployment {
+	return appDeployment(resource).Build()
+}
+
+func createHorizontalPodAutoscaler(resource ContainerIngress) *autoscalingv2.HorizontalPodAutoscaler {
+	return appDeployment(resource).HorizontalPodAutoscaler()
+}
+
+func appDeployment(resource ContainerIngress) builders.Deployment {
+	return builders.Deployment{
+		Name:        resource.Name,
+		Namespace:   resource.Namespace,
+		Replicas:    resource.Spec.Replicas,
+		Autoscaling: resource.Spec.Autoscaling,
+		Image:       resource.Spec.Image,
+		Port:        resource.Spec.ContainerPort,
+		Probes:      resource.Spec.Probes,
+		Resources:   resource.Spec.Resources,
+		Env:         builders.Env(nil, resource.Spec.Env),
+		EnvFrom:     builders.EnvFrom(resource.Spec.EnvFrom),
+	}
+}
+
+func createService(resource ContainerIngress) *corev1.Service {
+	return builders.Service{
+		Name:       resource.Name,
+		Namespace:  resource.Namespace,
+		PortName:   "http",
+		Port:       80,
+		TargetPort: intstr.FromInt32(resource.Spec.ContainerPort),
+		// The routes to other container ports go through Service ports of the same number.
+		AdditionalPorts: appIngress(resource).ServicePorts(),
+	}.Build()
+}
+
+func createIngress(resource ContainerIngress) *networkingv1.Ingress {
+	return appIngress(resource).Build()
+}
+
+func createHTTPProxies(resource ContainerIngress) []*unstructured.Unstructured {
+	return appIngress(resource).HTTPProxies()
+}
+
+func createCertificate(resource ContainerIngress) *unstructured.Unstructured {
+	return appIngress(resource).Certificate()
+}
+
+func createHTTPRoutes(resource ContainerIngress) []*unstructured.Unstructured {
+	return appIngress(resource).HTTPRoutes()
+}
+
+func appIngress(resource ContainerIngress) builders.Ingress {
+	return builders.Ingress{
+		Name:          resource.Name,
+		Namespace:     resource.Namespace,
+		Host:          resource.Spec.Host,
+		Path:          resource.Spec.Path,
+		Aliases:       resource.Spec.Aliases,
+		Routes:        resource.Spec.Routes,
+		ServiceName:   resource.Name,
+		ContainerPort: resource.Spec.ContainerPort,
+		TLSSecretName: resource.Spec.TLSSecretName,
+		TLS:           resource.Spec.TLS,
+		Spec:          resource.Spec.Ingress,
+	}
+}
+
+// createStatus returns the ContainerIngress with its status, which the ATC writes back since the resource shares the
+// group and kind of the Airway.
+func (r Renderer) createStatus(resource ContainerIngress) (*ContainerIngress, error) {
+	status := ContainerIngressStatus{
+		URL: appIngress(resource).URL(),
+	}
+
+	var err error
+	if status.App, err = appDeployment(resource).Status(r.LookupObject); err != nil {
+		return nil, err
+	}
+
+	resource.Status = status
+	return &resource, nil
+}
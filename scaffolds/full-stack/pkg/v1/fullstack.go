@@ -0,0 +1,153 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/stolos-cloud/test-template/pkg/builders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+const (
+	FullStackAPIVersion = "stolos.cloud/v1alpha1"
+	KindFullStack       = "FullStack"
+)
+
+const (
+	// RoutingSeparateHosts serves the backend and the frontend on their own host.
+	RoutingSeparateHosts = "separateHosts"
+	// RoutingSameHost serves the backend under spec.backend.path of the frontend host, so the frontend calls it without
+	// CORS.
+	RoutingSameHost = "sameHost"
+)
+
+// FullStack wires backend, database, cache, and frontend resources.
+type FullStack struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              FullStackSpec   `json:"spec"`
+	Status            FullStackStatus `json:"status,omitzero"`
+}
+
+// FullStackSpec enumerates nested config sections.
+type FullStackSpec struct {
+	// Routing is "separateHosts" or "sameHost". With sameHost, the frontend host, aliases, TLS and ingress settings
+	// apply to both and the backend ones are rejected.
+	Routing  string       `json:"routing,omitempty" Default:"\"separateHosts\""`
+	Backend  BackendSpec  `json:"backend"`
+	Frontend FrontendSpec `json:"frontend"`
+	Database DatabaseSpec `json:"database"`
+	Cache    CacheSpec    `json:"cache"`
+}
+
+// FullStackStatus is published by the flight from the deployed resources. The ATC maintains the Ready condition.
+type FullStackStatus struct {
+	Conditions    flight.Conditions        `json:"conditions,omitempty"`
+	FrontendURL   string                   `json:"frontendURL,omitempty"`
+	BackendURL    string                   `json:"backendURL,omitempty"`
+	DatabaseHost  string                   `json:"databaseHost,omitempty"`
+	CacheEndpoint string                   `json:"cacheEndpoint,omitempty"`
+	Frontend      builders.ComponentStatus `json:"frontend,omitzero"`
+	Backend       builders.ComponentStatus `json:"backend,omitzero"`
+	Database      builders.ComponentStatus `json:"database,omitzero"`
+	Cache         builders.ComponentStatus `json:"cache,omitzero"`
+}
+
+// BackendSpec configures the API deployment and ingress.
+type BackendSpec struct {
+	Image         string                    `json:"image"`
+	Replicas      int32                     `json:"replicas,omitempty" Default:"2"`
+	Autoscaling   *builders.AutoscalingSpec `json:"autoscaling,omitempty"`
+	ContainerPort int32                     `json:"containerPort,omitempty" Default:"8080"`
+	Probes        *builders.ProbesSpec      `json:"probes,omitempty"`
+	Resources     *builders.ResourcesSpec   `json:"resources,omitempty"`
+	// Env is appended after the variables injected by the template, so values may reference them with $(NAME).
+	Env           []builders.EnvVar        `json:"env,omitempty"`
+	EnvFrom       []builders.EnvFromSource `json:"envFrom,omitempty"`
+	Host          string                   `json:"host,omitempty"`
+	Path          string                   `json:"path,omitempty" Default:"\"/api\""`
+	TLSSecretName string                   `json:"tlsSecretName,omitempty"`
+	// TLS has cert-manager issue the certificate, stored in tlsSecretName when set.
+	TLS *builders.TLSSpec `json:"tls,omitempty"`
+	// Ingress selects the provider: contour for an HTTPProxy with timeouts, retries and weighted services, or gateway
+	// for a Gateway API HTTPRoute.
+	Ingress *builders.IngressSpec `json:"ingress,omitempty"`
+	// Aliases are more hosts serving the routes of host, e.g. the apex domain next to "www.".
+	Aliases []string `json:"aliases,omitempty"`
+	// Routes replace the host and path rule, e.g. to serve versioned API prefixes, other domains or other container
+	// ports. TLS covers every host.
+	Routes []builders.IngressRouteSpec `json:"routes,omitempty"`
+}
+
+// FrontendAssetsSpec copies the site from a directory of an image, e.g. the output of the frontend build, so nginx
+// keeps its stock image.
+type FrontendAssetsSpec struct {
+	Image string `json:"image"`
+	// Path is the directory of the site in the image.
+	Path string `json:"path,omitempty" Default:"\"/dist\""`
+	// Source is "initContainer", copying Path into an emptyDir with the cp of the image before nginx starts, or
+	// "imageVolume", mounting Path of the image as an OCI image volume (Kubernetes 1.33 or later), which also works
+	// for images without a shell.
+	Source string `json:"source,omitempty" Default:"\"initContainer\""`
+}
+
+// FrontendSpec configures the nginx deployment + ingress.
+type FrontendSpec struct {
+	Host          string `json:"host,omitempty"`
+	Path          string `json:"path,omitempty" Default:"\"/\""`
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+	// TLS has cert-manager issue the certificate, stored in tlsSecretName when set.
+	TLS *builders.TLSSpec `json:"tls,omitempty"`
+	// Ingress selects the provider: contour for an HTTPProxy with timeouts, retries and weighted services, or gateway
+	// for a Gateway API HTTPRoute.
+	Ingress *builders.IngressSpec `json:"ingress,omitempty"`
+	// Aliases are more hosts serving the frontend, e.g. the apex domain next to "www.".
+	Aliases     []string                  `json:"aliases,omitempty"`
+	Image       string                    `json:"image,omitempty" Default:"\"nginx:stable-alpine\""`
+	Replicas    int32                     `json:"replicas,omitempty" Default:"1"`
+	Autoscaling *builders.AutoscalingSpec `json:"autoscaling,omitempty"`
+	// Probes default to an HTTP GET on / for liveness and readiness.
+	Probes    *builders.ProbesSpec    `json:"probes,omitempty"`
+	Resources *builders.ResourcesSpec `json:"resources,omitempty"`
+	// StaticContent is served as index.html. A page pointing to the backend is generated when neither it, an
+	// index.html of Files nor Assets is set.
+	StaticContent string `json:"staticContent,omitempty"`
+	// Files are served next to index.html, by path relative to the site root, e.g. "assets/app.js". They are stored
+	// with StaticContent in a ConfigMap, so they add up to 1 MiB at most.
+	Files map[string]string `json:"files,omitempty"`
+	// BinaryFiles are Files given in base64, e.g. images or fonts.
+	BinaryFiles map[string]string `json:"binaryFiles,omitempty"`
+	// Assets serves a built bundle from an image instead of StaticContent and the files.
+	Assets *FrontendAssetsSpec `json:"assets,omitempty"`
+	// Nginx replaces the stock server configuration of the image, e.g. to serve a single-page application.
+	Nginx *builders.NginxSpec `json:"nginx,omitempty"`
+}
+
+// DatabaseSpec describes the CNPG cluster inputs.
+type DatabaseSpec = builders.DatabaseSpec
+
+// CacheSpec configures Redis / Valkey.
+type CacheSpec = builders.CacheSpec
+
+func (f FullStack) MarshalJSON() ([]byte, error) {
+	f.APIVersion = FullStackAPIVersion
+	f.Kind = KindFullStack
+	type Alias FullStack
+	return json.Marshal(Alias(f))
+}
+
+func (f *FullStack) UnmarshalJSON(data []byte) error {
+	type Alias FullStack
+	if err := json.Unmarshal(data, (*Alias)(f)); err != nil {
+		return err
+	}
+	if f.APIVersion != "" && f.APIVersion != FullStackAPIVersion {
+		return fmt.Errorf("unexpected api version: expected %s but got %s", FullStackAPIVersion, f.APIVersion)
+	}
+	if f.Kind != "" && f.Kind != KindFullStack {
+		return fmt.Errorf("unexpected kind: expected %s but got %s", KindFullStack, f.Kind)
+	}
+	return nil
+}
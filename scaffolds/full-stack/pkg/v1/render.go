@@ -0,0 +1,825 @@
+package v1
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/stolos-cloud/test-template/pkg/builders"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+// RenderFrom decodes a FullStack from r and renders it without reading the cluster, see Renderer.
+// We use the yaml to json decoder so that yaml definitions can be passed manually when testing for convenience.
+func RenderFrom(r io.Reader) ([]flight.Resource, error) {
+	return Renderer{}.RenderFrom(r)
+}
+
+// Render renders the FullStack without reading the cluster, see Renderer.
+func Render(resource FullStack) ([]flight.Resource, error) {
+	return Renderer{}.Render(resource)
+}
+
+// Renderer renders FullStacks with the cluster reads of the flight. The zero value reads nothing: it generates a new
+// cache password, reports components that do not exist yet and derives the hosts from the default base domain.
+type Renderer struct {
+	// LookupSecret reads the cache password Secret already in the cluster so the generated password survives
+	// re-renders.
+	LookupSecret builders.SecretLookup
+	// LookupObject reads the deployed workloads back to report their readiness in the FullStack status, and the
+	// StolosPlatform holding the base domain.
+	LookupObject builders.ObjectLookup
+	// Hosts derives the hostnames left empty in the FullStack. The flight sets it from AirwayInputs.yml.
+	Hosts builders.HostDefaults
+	// Now timestamps the status conditions. It defaults to metav1.Now.
+	Now func() metav1.Time
+}
+
+// RenderFrom decodes a FullStack from in and renders it.
+func (r Renderer) RenderFrom(in io.Reader) ([]flight.Resource, error) {
+	var resource FullStack
+	if err := yaml.NewYAMLToJSONDecoder(in).Decode(&resource); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return r.Render(resource)
+}
+
+// Render validates the FullStack, applies defaults, and returns the resources to deploy followed by the FullStack
+// itself carrying its status.
+// Apart from the LookupSecret and LookupObject reads it has no side effects so it can be called in-process by other
+// Go programs.
+func (r Renderer) Render(resource FullStack) ([]flight.Resource, error) {
+	if err := r.validateSpec(&resource); err != nil {
+		return nil, err
+	}
+
+	cachePasswordSecret, err := r.createCachePasswordSecret(resource)
+	if err != nil {
+		return nil, err
+	}
+	// Warnings do not fail the render.
+	warnings := resource.Spec.Database.Warnings("spec.database")
+	status, err := r.createStatus(resource, warnings)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []flight.Resource{
+		createBackendDeployment(resource),
+		createBackendService(resource),
+		createDatabaseCluster(resource),
+		createCacheConfigMap(resource),
+		createCacheWorkload(resource),
+		createCacheService(resource),
+	}
+	if headless := createCacheHeadlessService(resource); headless != nil {
+		resources = append(resources, headless)
+	}
+	if site := createFrontendConfigMap(resource); site != nil {
+		resources = append(resources, site)
+	}
+	resources = append(resources, createFrontendDeployment(resource), createFrontendService(resource))
+	if config := createFrontendNginxConfigMap(resource); config != nil {
+		resources = append(resources, config)
+	}
+	for _, hpa := range []*autoscalingv2.HorizontalPodAutoscaler{
+		createBackendHorizontalPodAutoscaler(resource),
+		createFrontendHorizontalPodAutoscaler(resource),
+	} {
+		if hpa != nil {
+			resources = append(resources, hpa)
+		}
+	}
+	for _, ingress := range []*networkingv1.Ingress{
+		createBackendIngress(resource),
+		createFrontendIngress(resource),
+	} {
+		if ingress != nil {
+			resources = append(resources, ingress)
+		}
+	}
+	for _, object := range slices.Concat(
+		createBackendHTTPProxies(resource),
+		createBackendHTTPRoutes(resource),
+		createFrontendHTTPProxies(resource),
+		createFrontendHTTPRoutes(resource),
+	) {
+		resources = append(resources, object)
+	}
+	for _, object := range []*unstructured.Unstructured{
+		createBackendCertificate(resource),
+		createFrontendCertificate(resource),
+		createDatabaseObjectStore(resource),
+		createDatabaseScheduledBackup(resource),
+		createDatabaseRecoveryObjectStore(resource),
+		createDatabaseExtensions(resource),
+	} {
+		if object != nil {
+			resources = append(resources, object)
+		}
+	}
+	for _, pooler := range createDatabasePoolers(resource) {
+		resources = append(resources, pooler)
+	}
+	if cachePasswordSecret != nil {
+		resources = append(resources, cachePasswordSecret)
+	}
+	resources = append(resources, status)
+
+	return resources, nil
+}
+
+func (r Renderer) validateSpec(resource *FullStack) error {
+	if resource.Spec.Backend.Image == "" {
+		return fmt.Errorf("spec.backend.image is required")
+	}
+	switch resource.Spec.Routing {
+	case "", RoutingSeparateHosts:
+	case RoutingSameHost:
+		if err := validateSameHost(resource.Spec); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("spec.routing %q must be separateHosts or sameHost", resource.Spec.Routing)
+	}
+	// The frontend is published under the name of the FullStack and the backend next to it, on distinct hosts so
+	// both ingress providers accept them.
+	if resource.Spec.Backend.Host == "" && !sameHost(*resource) {
+		host, err := r.Hosts.Host(r.LookupObject, backendHostName(*resource), resource.Namespace)
+		if err != nil {
+			return fmt.Errorf("spec.backend.host: %w", err)
+		}
+		resource.Spec.Backend.Host = host
+	}
+	if resource.Spec.Frontend.Host == "" {
+		host, err := r.Hosts.Host(r.LookupObject, resource.Name, resource.Namespace)
+		if err != nil {
+			return fmt.Errorf("spec.frontend.host: %w", err)
+		}
+		resource.Spec.Frontend.Host = host
+	}
+	if err := resource.Spec.Backend.Autoscaling.Validate("spec.backend.autoscaling"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Frontend.Autoscaling.Validate("spec.frontend.autoscaling"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Backend.TLS.Validate("spec.backend.tls"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Frontend.TLS.Validate("spec.frontend.tls"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Backend.Ingress.Validate("spec.backend.ingress"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Frontend.Ingress.Validate("spec.frontend.ingress"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Backend.Ingress.ValidateTLS("spec.backend", resource.Spec.Backend.TLS, resource.Spec.Backend.TLSSecretName); err != nil {
+		return err
+	}
+	if err := resource.Spec.Frontend.Ingress.ValidateTLS("spec.frontend", resource.Spec.Frontend.TLS, resource.Spec.Frontend.TLSSecretName); err != nil {
+		return err
+	}
+	// Contour only accepts a single HTTPProxy per fqdn, unlike Ingresses which controllers merge.
+	if !sameHost(*resource) && (resource.Spec.Backend.Ingress.IsContour() || resource.Spec.Frontend.Ingress.IsContour()) {
+		frontendHosts := frontendIngress(*resource).Hosts()
+		for _, host := range backendIngress(*resource).Hosts() {
+			if slices.Contains(frontendHosts, host) {
+				return fmt.Errorf("spec.backend and spec.frontend both serve %s, they must use distinct hosts with the contour provider", host)
+			}
+		}
+	}
+	if err := resource.Spec.Backend.Probes.Validate("spec.backend.probes"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Frontend.Probes.Validate("spec.frontend.probes"); err != nil {
+		return err
+	}
+	if err := validateFrontendSite(&resource.Spec.Frontend); err != nil {
+		return err
+	}
+	if err := resource.Spec.Frontend.Nginx.Validate("spec.frontend.nginx", cmp.Or(resource.Spec.Backend.Path, "/api")); err != nil {
+		return err
+	}
+	if err := resource.Spec.Backend.Resources.Validate("spec.backend.resources"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Frontend.Resources.Validate("spec.frontend.resources"); err != nil {
+		return err
+	}
+	if err := resource.Spec.Database.Validate("spec.database"); err != nil {
+		return err
+	}
+	if err := builders.ValidateEnv("spec.backend.env", resource.Spec.Backend.Env, backendInjectedEnv(*resource)); err != nil {
+		return err
+	}
+	if err := builders.ValidateEnvFrom("spec.backend.envFrom", resource.Spec.Backend.EnvFrom); err != nil {
+		return err
+	}
+	if err := resource.Spec.Cache.Validate("spec.cache"); err != nil {
+		return err
+	}
+	resource.Spec.Cache.SetDefaults()
+	if resource.Spec.Backend.Replicas <= 0 {
+		resource.Spec.Backend.Replicas = 2
+	}
+	if resource.Spec.Backend.ContainerPort == 0 {
+		resource.Spec.Backend.ContainerPort = 8080
+	}
+	if resource.Spec.Backend.Path == "" {
+		resource.Spec.Backend.Path = "/api"
+	}
+	if err := resource.Spec.Backend.Ingress.ValidateRoutes("spec.backend", resource.Spec.Backend.Routes, resource.Spec.Backend.Aliases, resource.Spec.Backend.ContainerPort); err != nil {
+		return err
+	}
+	if err := resource.Spec.Frontend.Ingress.ValidateRoutes("spec.frontend", nil, resource.Spec.Frontend.Aliases, 80); err != nil {
+		return err
+	}
+	if resource.Spec.Frontend.Replicas <= 0 {
+		resource.Spec.Frontend.Replicas = 1
+	}
+	if resource.Spec.Frontend.Path == "" {
+		resource.Spec.Frontend.Path = "/"
+	}
+	if resource.Spec.Frontend.Image == "" {
+		resource.Spec.Frontend.Image = "nginx:stable-alpine"
+	}
+	if resource.Spec.Routing == "" {
+		resource.Spec.Routing = RoutingSeparateHosts
+	}
+	resource.Spec.Backend.Autoscaling.SetDefaults()
+	resource.Spec.Frontend.Autoscaling.SetDefaults()
+	if err := resource.Spec.Backend.TLS.SetDefaults(r.LookupObject); err != nil {
+		return fmt.Errorf("spec.backend.tls: %w", err)
+	}
+	if err := resource.Spec.Frontend.TLS.SetDefaults(r.LookupObject); err != nil {
+		return fmt.Errorf("spec.frontend.tls: %w", err)
+	}
+	resource.Spec.Backend.Ingress.SetDefaults()
+	resource.Spec.Frontend.Ingress.SetDefaults()
+	resource.Spec.Database.SetDefaults()
+	if generatesIndex(resource.Spec.Frontend) {
+		if sameHost(*resource) {
+			resource.Spec.Frontend.StaticContent = fmt.Sprintf(`<!doctype html>
+<html>
+  <head>
+    <title>%s</title>
+  </head>
+  <body>
+    <h1>%s</h1>
+    <p>Your backend API is available at <a href="%s">%s</a>: <span id="api-status">calling it...</span></p>
+    <script>
+      fetch(%q)
+        .then((response) => response.status + " " + response.statusText)
+        .catch((error) => error.message)
+        .then((status) => { document.getElementById("api-status").textContent = status; });
+    </script>
+  </body>
+</html>`, resource.Name, resource.Name, resource.Spec.Backend.Path, resource.Spec.Backend.Path, resource.Spec.Backend.Path)
+		} else {
+			resource.Spec.Frontend.StaticContent = fmt.Sprintf(`<!doctype html>
+<html>
+  <head>
+    <title>%s</title>
+  </head>
+  <body>
+    <h1>%s</h1>
+    <p>Your backend API is available at https://%s%s</p>
+  </body>
+</html>`, resource.Name, resource.Name, resource.Spec.Backend.Host, resource.Spec.Backend.Path)
+		}
+	}
+	return nil
+}
+
+func createBackendDeployment(resource FullStack) *appsv1.Deployment {
+	return backendDeployment(resource).Build()
+}
+
+func createBackendHorizontalPodAutoscaler(resource FullStack) *autoscalingv2.HorizontalPodAutoscaler {
+	return backendDeployment(resource).HorizontalPodAutoscaler()
+}
+
+func backendDeployment(resource FullStack) builders.Deployment {
+	return builders.Deployment{
+		Name:        resource.Name,
+		Namespace:   resource.Namespace,
+		Replicas:    resource.Spec.Backend.Replicas,
+		Autoscaling: resource.Spec.Backend.Autoscaling,
+		Image:       resource.Spec.Backend.Image,
+		Port:        resource.Spec.Backend.ContainerPort,
+		Probes:      resource.Spec.Backend.Probes,
+		Resources:   resource.Spec.Backend.Resources,
+		Env:         builders.Env(backendInjectedEnv(resource), resource.Spec.Backend.Env),
+		EnvFrom:     builders.EnvFrom(resource.Spec.Backend.EnvFrom),
+	}
+}
+
+// backendInjectedEnv returns the variables the template sets on the backend container.
+func backendInjectedEnv(resource FullStack) []corev1.EnvVar {
+	return append(database(resource).Env(), cache(resource).Env()...)
+}
+
+func createBackendService(resource FullStack) *corev1.Service {
+	return builders.Service{
+		Name:       resource.Name,
+		Namespace:  resource.Namespace,
+		PortName:   "http",
+		Port:       80,
+		TargetPort: intstr.FromInt32(resource.Spec.Backend.ContainerPort),
+		// The routes to other container ports go through Service ports of the same number.
+		AdditionalPorts: backendIngress(resource).ServicePorts(),
+	}.Build()
+}
+
+func createBackendIngress(resource FullStack) *networkingv1.Ingress {
+	if sameHost(resource) {
+		return nil
+	}
+	return backendIngress(resource).Build()
+}
+
+func createBackendHTTPProxies(resource FullStack) []*unstructured.Unstructured {
+	if sameHost(resource) {
+		return nil
+	}
+	return backendIngress(resource).HTTPProxies()
+}
+
+func createBackendCertificate(resource FullStack) *unstructured.Unstructured {
+	if sameHost(resource) {
+		return nil
+	}
+	return backendIngress(resource).Certificate()
+}
+
+func createBackendHTTPRoutes(resource FullStack) []*unstructured.Unstructured {
+	if sameHost(resource) {
+		return nil
+	}
+	return backendIngress(resource).HTTPRoutes()
+}
+
+func backendIngress(resource FullStack) builders.Ingress {
+	return builders.Ingress{
+		Name:          resource.Name,
+		Namespace:     resource.Namespace,
+		Host:          resource.Spec.Backend.Host,
+		Path:          resource.Spec.Backend.Path,
+		Aliases:       resource.Spec.Backend.Aliases,
+		Routes:        resource.Spec.Backend.Routes,
+		ServiceName:   resource.Name,
+		ContainerPort: resource.Spec.Backend.ContainerPort,
+		TLSSecretName: resource.Spec.Backend.TLSSecretName,
+		TLS:           resource.Spec.Backend.TLS,
+		Spec:          resource.Spec.Backend.Ingress,
+	}
+}
+
+func createDatabaseCluster(resource FullStack) *unstructured.Unstructured {
+	return database(resource).Cluster()
+}
+
+func createDatabaseObjectStore(resource FullStack) *unstructured.Unstructured {
+	return database(resource).ObjectStore()
+}
+
+func createDatabaseScheduledBackup(resource FullStack) *unstructured.Unstructured {
+	return database(resource).ScheduledBackup()
+}
+
+func createDatabaseRecoveryObjectStore(resource FullStack) *unstructured.Unstructured {
+	return database(resource).RecoveryObjectStore()
+}
+
+func createDatabaseExtensions(resource FullStack) *unstructured.Unstructured {
+	return database(resource).Extensions()
+}
+
+func createDatabasePoolers(resource FullStack) []*unstructured.Unstructured {
+	return database(resource).Poolers()
+}
+
+// createCacheWorkload returns a StatefulSet when the cache is persisted, and a Deployment otherwise.
+func createCacheWorkload(resource FullStack) flight.Resource {
+	if resource.Spec.Cache.Persistence.IsEnabled() {
+		return cache(resource).StatefulSet()
+	}
+	return cache(resource).Deployment()
+}
+
+func createCacheConfigMap(resource FullStack) *corev1.ConfigMap {
+	return cache(resource).ConfigMap()
+}
+
+func (r Renderer) createCachePasswordSecret(resource FullStack) (*corev1.Secret, error) {
+	return cache(resource).PasswordSecret(r.LookupSecret)
+}
+
+func createCacheService(resource FullStack) *corev1.Service {
+	return cache(resource).Service()
+}
+
+// createCacheHeadlessService returns the Service governing the cache StatefulSet, or nil without persistence.
+func createCacheHeadlessService(resource FullStack) *corev1.Service {
+	return cache(resource).HeadlessService()
+}
+
+// createFrontendConfigMap returns the ConfigMap of the site, or nil when it comes from spec.frontend.assets.
+func createFrontendConfigMap(resource FullStack) *corev1.ConfigMap {
+	frontend := resource.Spec.Frontend
+	if frontend.Assets != nil {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.Identifier(), Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: frontendName(resource), Namespace: resource.Namespace},
+		Data:       map[string]string{},
+	}
+	if frontend.StaticContent != "" {
+		configMap.Data[frontendIndex] = frontend.StaticContent
+	}
+	for name, content := range frontend.Files {
+		configMap.Data[frontendFileKey(name)] = content
+	}
+	for name, content := range frontend.BinaryFiles {
+		if configMap.BinaryData == nil {
+			configMap.BinaryData = map[string][]byte{}
+		}
+		// validateFrontendSite checked the encoding.
+		configMap.BinaryData[frontendFileKey(name)], _ = base64.StdEncoding.DecodeString(content)
+	}
+	return configMap
+}
+
+// frontendIndex is the file nginx serves for the directories.
+const frontendIndex = "index.html"
+
+// frontendFileKey is the ConfigMap key of a site file, whose path may hold slashes unlike the keys.
+func frontendFileKey(name string) string {
+	return strings.ReplaceAll(name, "/", "__")
+}
+
+// frontendFilePaths returns the paths of the site files in the ConfigMap, sorted.
+func frontendFilePaths(frontend FrontendSpec) []string {
+	var names []string
+	if frontend.StaticContent != "" {
+		names = append(names, frontendIndex)
+	}
+	names = append(names, slices.Collect(maps.Keys(frontend.Files))...)
+	names = append(names, slices.Collect(maps.Keys(frontend.BinaryFiles))...)
+	slices.Sort(names)
+	return names
+}
+
+// frontendSiteVolume returns the volume holding the site, and the init container filling it when spec.frontend.assets
+// copies it from an image.
+func frontendSiteVolume(resource FullStack) (corev1.Volume, corev1.VolumeMount, []corev1.Container) {
+	mount := corev1.VolumeMount{Name: "site", MountPath: frontendRoot, ReadOnly: true}
+
+	assets := resource.Spec.Frontend.Assets
+	switch {
+	case assets == nil:
+		source := &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: frontendName(resource)}}
+		// Files in subdirectories are stored under other keys, the items put them back in place.
+		names := frontendFilePaths(resource.Spec.Frontend)
+		if slices.ContainsFunc(names, func(name string) bool { return frontendFileKey(name) != name }) {
+			for _, name := range names {
+				source.Items = append(source.Items, corev1.KeyToPath{Key: frontendFileKey(name), Path: name})
+			}
+		}
+		return corev1.Volume{Name: "site", VolumeSource: corev1.VolumeSource{ConfigMap: source}}, mount, nil
+	case assets.Source == frontendAssetsImageVolume:
+		mount.SubPath = strings.TrimPrefix(assets.Path, "/")
+		volume := corev1.Volume{
+			Name: "site",
+			VolumeSource: corev1.VolumeSource{
+				Image: &corev1.ImageVolumeSource{Reference: assets.Image, PullPolicy: corev1.PullIfNotPresent},
+			},
+		}
+		return volume, mount, nil
+	default:
+		copyAssets := corev1.Container{
+			Name:         "assets",
+			Image:        assets.Image,
+			Command:      []string{"cp", "-R", strings.TrimSuffix(assets.Path, "/") + "/.", frontendAssetsDir},
+			VolumeMounts: []corev1.VolumeMount{{Name: "site", MountPath: frontendAssetsDir}},
+		}
+		volume := corev1.Volume{Name: "site", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}
+		return volume, mount, []corev1.Container{copyAssets}
+	}
+}
+
+const (
+	frontendAssetsInitContainer = "initContainer"
+	frontendAssetsImageVolume   = "imageVolume"
+	// frontendAssetsDir is where the init container copies the site, away from the directories of the image.
+	frontendAssetsDir = "/stolos-site"
+	// frontendSiteLimit is the size limit of a ConfigMap, minus some room for its metadata.
+	frontendSiteLimit = 1000 * 1024
+)
+
+// frontendFileName is a relative path of the site, made of the characters ConfigMap keys accept.
+var frontendFileName = regexp.MustCompile(`^[-._a-zA-Z0-9]+(/[-._a-zA-Z0-9]+)*$`)
+
+// isFrontendFileName reports whether name is a frontendFileName without "." or ".." segments, whose ConfigMap key
+// Kubernetes accepts.
+func isFrontendFileName(name string) bool {
+	if !frontendFileName.MatchString(name) || len(validation.IsConfigMapKey(frontendFileKey(name))) > 0 {
+		return false
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// validateFrontendSite checks the site files or assets, and applies the defaults of the assets.
+func validateFrontendSite(frontend *FrontendSpec) error {
+	if assets := frontend.Assets; assets != nil {
+		switch {
+		case frontend.StaticContent != "" || len(frontend.Files) > 0 || len(frontend.BinaryFiles) > 0:
+			return fmt.Errorf("spec.frontend.assets cannot be combined with staticContent, files or binaryFiles")
+		case assets.Image == "":
+			return fmt.Errorf("spec.frontend.assets.image is required")
+		case assets.Path != "" && (!path.IsAbs(assets.Path) || path.Clean(assets.Path) != assets.Path):
+			return fmt.Errorf("spec.frontend.assets.path %q must be a clean absolute path, e.g. /dist", assets.Path)
+		}
+		switch assets.Source {
+		case "", frontendAssetsInitContainer, frontendAssetsImageVolume:
+		default:
+			return fmt.Errorf("spec.frontend.assets.source %q must be initContainer or imageVolume", assets.Source)
+		}
+		assets.Path = cmp.Or(assets.Path, "/dist")
+		assets.Source = cmp.Or(assets.Source, frontendAssetsInitContainer)
+		return nil
+	}
+
+	size := len(frontend.StaticContent)
+	keys := map[string]string{}
+	for _, files := range []struct {
+		field string
+		files map[string]string
+	}{
+		{"files", frontend.Files},
+		{"binaryFiles", frontend.BinaryFiles},
+	} {
+		for _, name := range slices.Sorted(maps.Keys(files.files)) {
+			filePath := fmt.Sprintf("spec.frontend.%s[%q]", files.field, name)
+			switch {
+			case !isFrontendFileName(name):
+				return fmt.Errorf("%s must be a relative path of letters, digits, '-', '_' and '.', without . or .. segments, e.g. assets/app.js", filePath)
+			case name == frontendIndex && frontend.StaticContent != "":
+				return fmt.Errorf("%s is already set by spec.frontend.staticContent", filePath)
+			}
+			if other, ok := keys[frontendFileKey(name)]; ok {
+				return fmt.Errorf("%s collides with %q, they would share a ConfigMap key", filePath, other)
+			}
+			keys[frontendFileKey(name)] = name
+
+			content := files.files[name]
+			if files.field == "binaryFiles" {
+				decoded, err := base64.StdEncoding.DecodeString(content)
+				if err != nil {
+					return fmt.Errorf("%s is not valid base64: %w", filePath, err)
+				}
+				content = string(decoded)
+			}
+			size += len(content)
+		}
+	}
+	if size > frontendSiteLimit {
+		return fmt.Errorf("spec.frontend files add up to %d bytes, over the %d a ConfigMap holds; serve them from spec.frontend.assets instead", size, frontendSiteLimit)
+	}
+	return nil
+}
+
+// generatesIndex reports whether the index.html pointing to the backend is generated for the frontend.
+func generatesIndex(frontend FrontendSpec) bool {
+	_, file := frontend.Files[frontendIndex]
+	_, binaryFile := frontend.BinaryFiles[frontendIndex]
+	return frontend.StaticContent == "" && !file && !binaryFile && frontend.Assets == nil
+}
+
+func createFrontendDeployment(resource FullStack) *appsv1.Deployment {
+	return frontendDeployment(resource).Build()
+}
+
+func createFrontendHorizontalPodAutoscaler(resource FullStack) *autoscalingv2.HorizontalPodAutoscaler {
+	return frontendDeployment(resource).HorizontalPodAutoscaler()
+}
+
+func frontendDeployment(resource FullStack) builders.Deployment {
+	siteVolume, siteMount, initContainers := frontendSiteVolume(resource)
+	deployment := builders.Deployment{
+		Name:           frontendName(resource),
+		Namespace:      resource.Namespace,
+		Replicas:       resource.Spec.Frontend.Replicas,
+		Autoscaling:    resource.Spec.Frontend.Autoscaling,
+		ContainerName:  "frontend",
+		Image:          resource.Spec.Frontend.Image,
+		Port:           80,
+		Probes:         resource.Spec.Frontend.Probes.WithDefaults(builders.HTTPProbes("/")),
+		Resources:      resource.Spec.Frontend.Resources,
+		Volumes:        []corev1.Volume{siteVolume},
+		VolumeMounts:   []corev1.VolumeMount{siteMount},
+		InitContainers: initContainers,
+	}
+	if resource.Spec.Frontend.Nginx != nil {
+		nginx := frontendNginx(resource)
+		deployment.Volumes = append(deployment.Volumes, nginx.Volume())
+		deployment.VolumeMounts = append(deployment.VolumeMounts, nginx.VolumeMount())
+		deployment.PodAnnotations = map[string]string{"checksum/nginx-config": nginx.ConfigChecksum()}
+	}
+	return deployment
+}
+
+func createFrontendNginxConfigMap(resource FullStack) *corev1.ConfigMap {
+	if resource.Spec.Frontend.Nginx == nil {
+		return nil
+	}
+	return frontendNginx(resource).ConfigMap()
+}
+
+// frontendNginx replaces the stock server configuration of the frontend when spec.frontend.nginx is set.
+func frontendNginx(resource FullStack) builders.NginxServer {
+	return builders.NginxServer{
+		Name:        frontendName(resource) + "-nginx",
+		Namespace:   resource.Namespace,
+		Spec:        resource.Spec.Frontend.Nginx,
+		Root:        frontendRoot,
+		BackendPath: resource.Spec.Backend.Path,
+		BackendURL:  fmt.Sprintf("http://%s", resource.Name),
+	}
+}
+
+// frontendRoot is the site directory of the nginx images.
+const frontendRoot = "/usr/share/nginx/html"
+
+func createFrontendService(resource FullStack) *corev1.Service {
+	return builders.Service{
+		Name:      frontendName(resource),
+		Namespace: resource.Namespace,
+		PortName:  "http",
+		Port:      80,
+	}.Build()
+}
+
+func createFrontendIngress(resource FullStack) *networkingv1.Ingress {
+	return frontendIngress(resource).Build()
+}
+
+func createFrontendHTTPProxies(resource FullStack) []*unstructured.Unstructured {
+	return frontendIngress(resource).HTTPProxies()
+}
+
+func createFrontendCertificate(resource FullStack) *unstructured.Unstructured {
+	return frontendIngress(resource).Certificate()
+}
+
+func createFrontendHTTPRoutes(resource FullStack) []*unstructured.Unstructured {
+	return frontendIngress(resource).HTTPRoutes()
+}
+
+func frontendIngress(resource FullStack) builders.Ingress {
+	return builders.Ingress{
+		Name:          frontendName(resource),
+		Namespace:     resource.Namespace,
+		Host:          resource.Spec.Frontend.Host,
+		Path:          resource.Spec.Frontend.Path,
+		Aliases:       resource.Spec.Frontend.Aliases,
+		PathServices:  frontendPathServices(resource),
+		ServiceName:   frontendName(resource),
+		TLSSecretName: resource.Spec.Frontend.TLSSecretName,
+		TLS:           resource.Spec.Frontend.TLS,
+		Spec:          resource.Spec.Frontend.Ingress,
+	}
+}
+
+// frontendPathServices routes the backend path of the frontend host to the backend with the sameHost routing.
+func frontendPathServices(resource FullStack) []builders.IngressPathService {
+	if !sameHost(resource) {
+		return nil
+	}
+	return []builders.IngressPathService{
+		{Path: resource.Spec.Backend.Path, ServiceName: resource.Name},
+	}
+}
+
+// createStatus returns the FullStack with its status. The ATC writes the status of the resource matching the group and
+// kind of the Airway instead of applying it, hence FullStackAPIVersion.
+func (r Renderer) createStatus(resource FullStack, warnings []string) (*FullStack, error) {
+	status := FullStackStatus{
+		FrontendURL:   frontendIngress(resource).URL(),
+		BackendURL:    backendURL(resource),
+		DatabaseHost:  database(resource).Host(),
+		CacheEndpoint: cache(resource).Endpoint(),
+	}
+
+	var err error
+	if status.Frontend, err = frontendDeployment(resource).Status(r.LookupObject); err != nil {
+		return nil, err
+	}
+	if status.Backend, err = backendDeployment(resource).Status(r.LookupObject); err != nil {
+		return nil, err
+	}
+	if status.Database, err = database(resource).Status(r.LookupObject); err != nil {
+		return nil, err
+	}
+	if status.Cache, err = cache(resource).Status(r.LookupObject); err != nil {
+		return nil, err
+	}
+	status.Conditions = builders.WarningsConditions(resource.Status.Conditions, resource.Generation, r.now(), warnings)
+
+	resource.Status = status
+	return &resource, nil
+}
+
+func (r Renderer) now() metav1.Time {
+	if r.Now == nil {
+		return metav1.Now()
+	}
+	return r.Now()
+}
+
+// validateSameHost rejects the backend settings that the frontend ones replace with the sameHost routing, and the
+// paths that would overlap.
+func validateSameHost(spec FullStackSpec) error {
+	backend := spec.Backend
+	var field string
+	switch {
+	case backend.Host != "":
+		field = "host"
+	case len(backend.Aliases) > 0:
+		field = "aliases"
+	case len(backend.Routes) > 0:
+		field = "routes"
+	case backend.TLSSecretName != "":
+		field = "tlsSecretName"
+	case backend.TLS.IsEnabled():
+		field = "tls"
+	case backend.Ingress != nil:
+		field = "ingress"
+	}
+	if field != "" {
+		return fmt.Errorf("spec.backend.%s is not supported with spec.routing sameHost, the spec.frontend settings apply to both", field)
+	}
+	if spec.Frontend.Path != "" && spec.Frontend.Path != "/" {
+		return fmt.Errorf("spec.frontend.path must be / with spec.routing sameHost")
+	}
+	if backend.Path == "/" {
+		return fmt.Errorf("spec.backend.path cannot be / with spec.routing sameHost, the frontend is served there")
+	}
+	return nil
+}
+
+// sameHost reports whether the backend is served on the frontend host.
+func sameHost(resource FullStack) bool {
+	return resource.Spec.Routing == RoutingSameHost
+}
+
+// backendURL is the address of the backend, under the frontend one with the sameHost routing.
+func backendURL(resource FullStack) string {
+	if sameHost(resource) {
+		return strings.TrimSuffix(frontendIngress(resource).URL(), "/") + resource.Spec.Backend.Path
+	}
+	return backendIngress(resource).URL()
+}
+
+// backendHostName is the {name} of the default backend host.
+func backendHostName(resource FullStack) string {
+	return fmt.Sprintf("%s-api", resource.Name)
+}
+
+func frontendName(resource FullStack) string {
+	return fmt.Sprintf("%s-frontend", resource.Name)
+}
+
+func database(resource FullStack) builders.Database {
+	return builders.Database{Namespace: resource.Namespace, Spec: resource.Spec.Database}
+}
+
+func cache(resource FullStack) builders.Cache {
+	return builders.Cache{AppName: resource.Name, Namespace: resource.Namespace, Spec: resource.Spec.Cache}
+}
@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stolos-cloud/test-template/pkg/builders"
+	"github.com/stolos-cloud/test-template/pkg/flighttest"
+	"github.com/yokecd/yoke/pkg/flight/wasi/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// lookupGoldenSecret pretends every generated Secret already exists so the golden files do not depend on random
+// passwords.
+func lookupGoldenSecret(namespace, name string) (*corev1.Secret, error) {
+	return &corev1.Secret{Data: map[string][]byte{"password": []byte("golden-password")}}, nil
+}
+
+// goldenNow keeps the transition time of the status conditions out of the golden files.
+func goldenNow() metav1.Time {
+	return metav1.NewTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestRender(t *testing.T) {
+	renderer := Renderer{LookupSecret: lookupGoldenSecret, Now: goldenNow}
+
+	flighttest.Run(t, flighttest.JSON(renderer.RenderFrom), "../../*.yaml.example", "testdata/*.yaml")
+}
+
+func TestRenderStatus(t *testing.T) {
+	// The backend is ready, the frontend is rolling out, the database is healthy and the cache is not created yet.
+	deployed := map[string]map[string]interface{}{
+		"Deployment/storefront": {
+			"spec":   map[string]interface{}{"replicas": int64(2)},
+			"status": map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(2), "updatedReplicas": int64(2)},
+		},
+		"Deployment/storefront-frontend": {
+			"spec":   map[string]interface{}{"replicas": int64(2)},
+			"status": map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(1), "updatedReplicas": int64(1)},
+		},
+		"Cluster/storefront-db": {
+			"spec":   map[string]interface{}{"instances": int64(2)},
+			"status": map[string]interface{}{"readyInstances": int64(2), "phase": "Cluster in healthy state"},
+		},
+	}
+	renderer := Renderer{
+		LookupSecret: lookupGoldenSecret,
+		Now:          goldenNow,
+		LookupObject: func(apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
+			object, ok := deployed[kind+"/"+name]
+			if !ok {
+				return nil, nil
+			}
+			object["metadata"] = map[string]interface{}{"name": name, "namespace": namespace, "generation": int64(1)}
+			return &unstructured.Unstructured{Object: object}, nil
+		},
+	}
+
+	flighttest.Run(t, flighttest.JSON(renderer.RenderFrom), "testdata/status/*.yaml")
+}
+
+func TestRenderHosts(t *testing.T) {
+	renderer := Renderer{
+		LookupSecret: lookupGoldenSecret,
+		Now:          goldenNow,
+		// Only the StolosPlatform exists, the hosts are derived from its base domain and the certificates use its issuer.
+		LookupObject: func(apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
+			if kind != "StolosPlatform" {
+				return nil, nil
+			}
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": name},
+				"spec": map[string]interface{}{
+					"baseDomain":  "apps.example.org",
+					"certManager": map[string]interface{}{"defaultClusterIssuer": "letsencrypt-prod"},
+				},
+			}}, nil
+		},
+		Hosts: builders.HostDefaults{Pattern: "{name}-{namespace}.{baseDomain}"},
+	}
+
+	flighttest.Run(t, flighttest.JSON(renderer.RenderFrom), "testdata/hosts/*.yaml")
+}
+
+func TestRenderPlatformForbidden(t *testing.T) {
+	// The Airway grants no access to the StolosPlatform, the hosts fall back to the default base domain.
+	renderer := Renderer{
+		LookupSecret: lookupGoldenSecret,
+		Now:          goldenNow,
+		LookupObject: func(apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
+			if kind == "StolosPlatform" {
+				return nil, k8s.ErrorForbidden("cannot access resource outside of target release ownership")
+			}
+			return nil, nil
+		},
+	}
+
+	flighttest.Run(t, flighttest.JSON(renderer.RenderFrom), "testdata/platform-forbidden/*.yaml")
+}
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/stolos-cloud/test-template/pkg/builders"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yokecd/yoke/pkg/flight"
 )
 
 const (
@@ -13,21 +16,36 @@ const (
 )
 
 // Backend is the type representing our CustomResource.
-// It contains Type and Object meta as found in typical kubernetes objects and a spec.
-// Do not provide a Status Object as that is automatically generated by the ATC.
+// It contains Type and Object meta as found in typical kubernetes objects, a spec, and the status published by the
+// flight. The ATC maintains the Ready condition of the status.
 type Backend struct {
 	metav1.TypeMeta
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              BackendSpec `json:"spec"`
+	Spec              BackendSpec   `json:"spec"`
+	Status            BackendStatus `json:"status,omitzero"`
+}
+
+// BackendStatus reports where the backend is reachable and whether it is ready.
+type BackendStatus struct {
+	Conditions flight.Conditions `json:"conditions,omitempty"`
+	// Endpoint is the in-cluster address of the Service, e.g. "api:80".
+	Endpoint string                   `json:"endpoint,omitempty"`
+	App      builders.ComponentStatus `json:"app,omitzero"`
 }
 
 // Our Backend Specification
 type BackendSpec struct {
-	Image       string            `json:"image"`
-	Replicas    int32             `json:"replicas" Default:"1"`
-	Labels      map[string]string `json:"labels,omitempty"`
-	NodePort    int               `json:"nodePort,omitempty"`
-	ServicePort int               `json:"port" Default:"80"`
+	Image       string                    `json:"image"`
+	Replicas    int32                     `json:"replicas" Default:"1"`
+	Autoscaling *builders.AutoscalingSpec `json:"autoscaling,omitempty"`
+	Probes      *builders.ProbesSpec      `json:"probes,omitempty"`
+	Resources   *builders.ResourcesSpec   `json:"resources,omitempty"`
+	// Env is appended after the variables injected by the template, so values may reference them with $(NAME).
+	Env         []builders.EnvVar        `json:"env,omitempty"`
+	EnvFrom     []builders.EnvFromSource `json:"envFrom,omitempty"`
+	Labels      map[string]string        `json:"labels,omitempty"`
+	NodePort    int                      `json:"nodePort,omitempty"`
+	ServicePort int                      `json:"port" Default:"80"`
 }
 
 // Custom Marshalling Logic so that users do not need to explicity fill out the Kind and ApiVersion.
@@ -0,0 +1,166 @@
+package v1
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/stolos-cloud/test-template/pkg/builders"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+// RenderFrom decodes a Backend from r and renders it without reading the cluster, see Renderer.
+// We can use the yaml to json decoder so that we can pass yaml definitions manually when testing for convenience.
+func RenderFrom(r io.Reader) ([]flight.Resource, error) {
+	return Renderer{}.RenderFrom(r)
+}
+
+// Render renders the Backend without reading the cluster, see Renderer.
+func Render(backend Backend) ([]flight.Resource, error) {
+	return Renderer{}.Render(backend)
+}
+
+// Renderer renders Backends with the cluster reads of the flight. The zero value reads nothing: it reports a backend that does not exist yet.
+type Renderer struct {
+	// LookupObject reads the deployed Deployment back to report its readiness in the Backend status.
+	LookupObject builders.ObjectLookup
+}
+
+// RenderFrom decodes a Backend from in and renders it.
+func (r Renderer) RenderFrom(in io.Reader) ([]flight.Resource, error) {
+	var backend Backend
+	if err := yaml.NewYAMLToJSONDecoder(in).Decode(&backend); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return r.Render(backend)
+}
+
+// Render returns the resources (Deployment and Service) for our backend, followed by the backend itself carrying its
+// status.
+// Apart from the LookupObject reads it has no side effects so it can be called in-process by other Go programs.
+func (r Renderer) Render(backend Backend) ([]flight.Resource, error) {
+	// Configure some sane defaults
+	backend.Spec.ServicePort = cmp.Or(backend.Spec.ServicePort, 3000)
+
+	// Replicas are owned by the HorizontalPodAutoscaler when autoscaling is enabled.
+	if backend.Spec.Replicas == 0 && !backend.Spec.Autoscaling.IsEnabled() {
+		return nil, fmt.Errorf("replicas cannot be 0")
+	}
+	if err := backend.Spec.Autoscaling.Validate("spec.autoscaling"); err != nil {
+		return nil, err
+	}
+	backend.Spec.Autoscaling.SetDefaults()
+	if err := backend.Spec.Probes.Validate("spec.probes"); err != nil {
+		return nil, err
+	}
+	if err := backend.Spec.Resources.Validate("spec.resources"); err != nil {
+		return nil, err
+	}
+	if err := builders.ValidateEnv("spec.env", backend.Spec.Env, injectedEnv(backend)); err != nil {
+		return nil, err
+	}
+	if err := builders.ValidateEnvFrom("spec.envFrom", backend.Spec.EnvFrom); err != nil {
+		return nil, err
+	}
+
+	// Our labels always include our custom selector, the Deployment builder merges it in.
+	resources := []flight.Resource{
+		createDeployment(backend),
+		createService(backend),
+	}
+	if hpa := createHorizontalPodAutoscaler(backend); hpa != nil {
+		resources = append(resources, hpa)
+	}
+
+	status, err := r.createStatus(backend)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, status)
+
+	return resources, nil
+}
+
+// The following functions create standard kubernetes resources from our backend resource definition.
+// They rely on the shared builders from `github.com/stolos-cloud/test-template/pkg/builders`, which return the base types
+// found in `k8s.io/api`. This is essentially the same as writing the types free-hand via yaml except that we have strong
+// typing, type-checking, and documentation at our finger tips, and fixes to the builders land in every template.
+
+func createDeployment(backend Backend) *appsv1.Deployment {
+	return deployment(backend).Build()
+}
+
+func createHorizontalPodAutoscaler(backend Backend) *autoscalingv2.HorizontalPodAutoscaler {
+	return deployment(backend).HorizontalPodAutoscaler()
+}
+
+func deployment(backend Backend) builders.Deployment {
+	return builders.Deployment{
+		Name:            backend.Name,
+		Namespace:       backend.Namespace,
+		Selector:        selector(backend),
+		Labels:          backend.Spec.Labels,
+		Replicas:        backend.Spec.Replicas,
+		Autoscaling:     backend.Spec.Autoscaling,
+		Image:           backend.Spec.Image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		PortName:        backend.Name,
+		Port:            int32(backend.Spec.ServicePort),
+		Probes:          backend.Spec.Probes,
+		Resources:       backend.Spec.Resources,
+		Env:             builders.Env(injectedEnv(backend), backend.Spec.Env),
+		EnvFrom:         builders.EnvFrom(backend.Spec.EnvFrom),
+	}
+}
+
+// The environment variables we always set on the backend container.
+func injectedEnv(backend Backend) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{
+			Name:  "PORT",
+			Value: strconv.Itoa(backend.Spec.ServicePort),
+		},
+	}
+}
+
+func createService(backend Backend) *corev1.Service {
+	return service(backend).Build()
+}
+
+func service(backend Backend) builders.Service {
+	return builders.Service{
+		Name:       backend.Name,
+		Namespace:  backend.Namespace,
+		Selector:   selector(backend),
+		Port:       80,
+		TargetPort: intstr.FromString(backend.Name),
+		NodePort:   int32(backend.Spec.NodePort),
+	}
+}
+
+// Our selector for our backend application. Independent from the regular labels passed in the backend spec.
+func selector(backend Backend) map[string]string {
+	return map[string]string{"app": backend.Name}
+}
+
+// createStatus returns the backend with its status. The ATC recognizes its own custom resource in the flight output
+// and writes the status instead of applying it.
+func (r Renderer) createStatus(backend Backend) (*Backend, error) {
+	app, err := deployment(backend).Status(r.LookupObject)
+	if err != nil {
+		return nil, err
+	}
+	svc := service(backend)
+	backend.Status = BackendStatus{
+		Endpoint: fmt.Sprintf("%s:%d", svc.Name, svc.Port),
+		App:      app,
+	}
+	return &backend, nil
+}